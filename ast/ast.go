@@ -3,12 +3,15 @@ package ast
 import (
 	"Go-Tutorials/Core-lang/token"
 	"bytes"
+	"math/big"
 	"strings"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos returns the position of the first character of the node.
+	Pos() token.Position
 }
 
 type Statement interface {
@@ -23,6 +26,7 @@ type Expression interface {
 
 type Program struct {
 	Statements []Statement
+	Comments   []*Comment // only filled in when the lexer scans comments
 }
 
 func (pro *Program) TokenLiteral() string {
@@ -33,6 +37,13 @@ func (pro *Program) TokenLiteral() string {
 	}
 }
 
+func (pro *Program) Pos() token.Position {
+	if len(pro.Statements) > 0 {
+		return pro.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 func (prg *Program) String() string {
 	var out bytes.Buffer
 
@@ -43,21 +54,39 @@ func (prg *Program) String() string {
 	return out.String()
 }
 
+// Comment is a "//" or "/* */" comment kept as trivia for tools such as
+// formatters. It is neither a statement nor an expression.
+type Comment struct {
+	Token token.Token // the token.COMMENT token
+}
+
+func (cmt *Comment) TokenLiteral() string { return cmt.Token.Literal }
+func (cmt *Comment) Pos() token.Position  { return cmt.Token.Pos }
+func (cmt *Comment) String() string       { return cmt.Token.Literal }
+
 type VarStatement struct {
 	Token token.Token // the token.VAR token
 	Name  *Identifier
-	Type  string //storing the variable type
+	Type  TypeExpression // the declared type, nil for var x = value;
 	Value Expression
+
+	// InferredType is the type of Value found by the type checker for a
+	// variable declared without a type. It is nil until the program has been
+	// checked, and stays nil when the type cannot be written down.
+	InferredType TypeExpression
 }
 
 func (vs *VarStatement) statementNode()       {}
 func (vs *VarStatement) TokenLiteral() string { return vs.Token.Literal }
+func (vs *VarStatement) Pos() token.Position  { return vs.Token.Pos }
 func (vs *VarStatement) String() string {
 	var output bytes.Buffer
 
 	output.WriteString("var ")
-	output.WriteString(vs.Type) // Include the variable type
-	output.WriteString(" ")
+	if vs.Type != nil {
+		output.WriteString(vs.Type.String()) // Include the variable type
+		output.WriteString(" ")
+	}
 	output.WriteString(vs.Name.String())
 	output.WriteString(" = ")
 
@@ -77,6 +106,7 @@ type Identifier struct {
 
 func (idr *Identifier) expressionNode()      {}
 func (idr *Identifier) TokenLiteral() string { return idr.Token.Literal }
+func (idr *Identifier) Pos() token.Position  { return idr.Token.Pos }
 func (idr *Identifier) String() string       { return idr.Value }
 
 type ReturnStatement struct {
@@ -86,6 +116,7 @@ type ReturnStatement struct {
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Position  { return rs.Token.Pos }
 func (rs *ReturnStatement) String() string {
 	var output bytes.Buffer
 
@@ -100,6 +131,115 @@ func (rs *ReturnStatement) String() string {
 	return output.String()
 }
 
+// FunctionStatement declares a named function. Declarations are hoisted:
+// they are bound before any other statement of their block runs.
+type FunctionStatement struct {
+	Token    token.Token // the 'function' token
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (fs *FunctionStatement) statementNode()       {}
+func (fs *FunctionStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *FunctionStatement) Pos() token.Position  { return fs.Token.Pos }
+func (fs *FunctionStatement) String() string       { return fs.Function.String() }
+
+type WhileStatement struct {
+	Token     token.Token // the 'while' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) Pos() token.Position  { return ws.Token.Pos }
+func (ws *WhileStatement) String() string {
+	return "while (" + ws.Condition.String() + ") " + ws.Body.String()
+}
+
+// ForStatement is a C-style loop. Init, Condition and Post are optional;
+// a missing Condition loops until a break or return.
+type ForStatement struct {
+	Token     token.Token // the 'for' token
+	Init      Statement
+	Condition Expression
+	Post      Expression
+	Body      *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Pos() token.Position  { return fs.Token.Pos }
+func (fs *ForStatement) String() string {
+	var output bytes.Buffer
+
+	output.WriteString("for (")
+	if fs.Init != nil {
+		// a var statement already ends with its own semicolon
+		output.WriteString(strings.TrimSuffix(fs.Init.String(), ";"))
+	}
+	output.WriteString("; ")
+	if fs.Condition != nil {
+		output.WriteString(fs.Condition.String())
+	}
+	output.WriteString("; ")
+	if fs.Post != nil {
+		output.WriteString(fs.Post.String())
+	}
+	output.WriteString(") ")
+	output.WriteString(fs.Body.String())
+
+	return output.String()
+}
+
+// ForInStatement iterates over a collection. With a single variable it is
+// bound to each element (each key for a hash); with two, Key is bound to the
+// index or key and Value to the element.
+type ForInStatement struct {
+	Token    token.Token // the 'for' token
+	Key      *Identifier // nil unless two variables are given
+	Value    *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fis *ForInStatement) statementNode()       {}
+func (fis *ForInStatement) TokenLiteral() string { return fis.Token.Literal }
+func (fis *ForInStatement) Pos() token.Position  { return fis.Token.Pos }
+func (fis *ForInStatement) String() string {
+	var output bytes.Buffer
+
+	output.WriteString("for (")
+	if fis.Key != nil {
+		output.WriteString(fis.Key.String() + ", ")
+	}
+	output.WriteString(fis.Value.String())
+	output.WriteString(" in ")
+	output.WriteString(fis.Iterable.String())
+	output.WriteString(") ")
+	output.WriteString(fis.Body.String())
+
+	return output.String()
+}
+
+type BreakStatement struct {
+	Token token.Token // the 'break' token
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BreakStatement) String() string       { return bs.Token.Literal + ";" }
+
+type ContinueStatement struct {
+	Token token.Token // the 'continue' token
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) Pos() token.Position  { return cs.Token.Pos }
+func (cs *ContinueStatement) String() string       { return cs.Token.Literal + ";" }
+
 type ExpressionStatement struct {
 	Token      token.Token // the 1st token of the expression
 	Expression Expression
@@ -107,6 +247,7 @@ type ExpressionStatement struct {
 
 func (exs *ExpressionStatement) statementNode()       {}
 func (exs *ExpressionStatement) TokenLiteral() string { return exs.Token.Literal }
+func (exs *ExpressionStatement) Pos() token.Position  { return exs.Token.Pos }
 func (exs *ExpressionStatement) String() string {
 	if exs.Expression != nil {
 		return exs.Expression.String()
@@ -117,12 +258,24 @@ func (exs *ExpressionStatement) String() string {
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int // the value of a literal outside the int64 range, else nil
 }
 
 func (inl *IntegerLiteral) expressionNode()      {}
 func (inl *IntegerLiteral) TokenLiteral() string { return inl.Token.Literal }
+func (inl *IntegerLiteral) Pos() token.Position  { return inl.Token.Pos }
 func (inl *IntegerLiteral) String() string       { return inl.Token.Literal }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fll *FloatLiteral) expressionNode()      {}
+func (fll *FloatLiteral) TokenLiteral() string { return fll.Token.Literal }
+func (fll *FloatLiteral) Pos() token.Position  { return fll.Token.Pos }
+func (fll *FloatLiteral) String() string       { return fll.Token.Literal }
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -131,6 +284,7 @@ type PrefixExpression struct {
 
 func (pre *PrefixExpression) expressionNode()      {}
 func (pre *PrefixExpression) TokenLiteral() string { return pre.Token.Literal }
+func (pre *PrefixExpression) Pos() token.Position  { return pre.Token.Pos }
 func (pre *PrefixExpression) String() string {
 	var output bytes.Buffer
 
@@ -151,6 +305,12 @@ type InfixExpression struct {
 
 func (ine *InfixExpression) expressionNode()      {}
 func (ine *InfixExpression) TokenLiteral() string { return ine.Token.Literal }
+func (ine *InfixExpression) Pos() token.Position {
+	if ine.Left != nil {
+		return ine.Left.Pos()
+	}
+	return ine.Token.Pos
+}
 func (ine *InfixExpression) String() string {
 	var output bytes.Buffer
 
@@ -163,6 +323,97 @@ func (ine *InfixExpression) String() string {
 	return output.String()
 }
 
+// AssignExpression stores Value into Target, which is an Identifier or an
+// IndexExpression. Operator is "=" or a compound form such as "+=".
+type AssignExpression struct {
+	Token    token.Token // the assignment operator token
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) Pos() token.Position {
+	if ae.Target != nil {
+		return ae.Target.Pos()
+	}
+	return ae.Token.Pos
+}
+func (ae *AssignExpression) String() string {
+	var output bytes.Buffer
+
+	output.WriteString("(")
+	output.WriteString(ae.Target.String())
+	output.WriteString(" " + ae.Operator + " ")
+	output.WriteString(ae.Value.String())
+	output.WriteString(")")
+
+	return output.String()
+}
+
+// UpdateExpression is an increment or decrement, either prefix (++x),
+// which yields the new value, or postfix (x++), which yields the old one.
+type UpdateExpression struct {
+	Token    token.Token // the ++ or -- token
+	Operator string
+	Target   Expression
+	Prefix   bool
+}
+
+func (ue *UpdateExpression) expressionNode()      {}
+func (ue *UpdateExpression) TokenLiteral() string { return ue.Token.Literal }
+func (ue *UpdateExpression) Pos() token.Position {
+	if !ue.Prefix && ue.Target != nil {
+		return ue.Target.Pos()
+	}
+	return ue.Token.Pos
+}
+func (ue *UpdateExpression) String() string {
+	if ue.Prefix {
+		return "(" + ue.Operator + ue.Target.String() + ")"
+	}
+	return "(" + ue.Target.String() + ue.Operator + ")"
+}
+
+// LogicalExpression is a short-circuiting && or || expression. It is kept
+// apart from InfixExpression because its right operand is evaluated lazily.
+type LogicalExpression struct {
+	Token    token.Token // the && or || token
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (le *LogicalExpression) expressionNode()      {}
+func (le *LogicalExpression) TokenLiteral() string { return le.Token.Literal }
+func (le *LogicalExpression) Pos() token.Position {
+	if le.Left != nil {
+		return le.Left.Pos()
+	}
+	return le.Token.Pos
+}
+func (le *LogicalExpression) String() string {
+	var output bytes.Buffer
+
+	output.WriteString("(")
+	output.WriteString(le.Left.String())
+	output.WriteString(" " + le.Operator + " ")
+	output.WriteString(le.Right.String())
+	output.WriteString(")")
+
+	return output.String()
+}
+
+type NullLiteral struct {
+	Token token.Token // the 'null' token
+}
+
+func (nl *NullLiteral) expressionNode()      {}
+func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+func (nl *NullLiteral) Pos() token.Position  { return nl.Token.Pos }
+func (nl *NullLiteral) String() string       { return nl.Token.Literal }
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -170,8 +421,37 @@ type StringLiteral struct {
 
 func (strl *StringLiteral) expressionNode()      {}
 func (strl *StringLiteral) TokenLiteral() string { return strl.Token.Literal }
+func (strl *StringLiteral) Pos() token.Position  { return strl.Token.Pos }
 func (strl *StringLiteral) String() string       { return strl.Token.Literal }
 
+// InterpolatedString is a string with embedded expressions such as
+// "hello ${name}". Strings holds the text around the expressions, so it has
+// exactly one element more than Expressions.
+type InterpolatedString struct {
+	Token       token.Token // the token.STRING_HEAD token
+	Strings     []string
+	Expressions []Expression
+}
+
+func (ins *InterpolatedString) expressionNode()      {}
+func (ins *InterpolatedString) TokenLiteral() string { return ins.Token.Literal }
+func (ins *InterpolatedString) Pos() token.Position  { return ins.Token.Pos }
+func (ins *InterpolatedString) String() string {
+	var output bytes.Buffer
+
+	output.WriteString("\"")
+	for i, expression := range ins.Expressions {
+		output.WriteString(ins.Strings[i])
+		output.WriteString("${")
+		output.WriteString(expression.String())
+		output.WriteString("}")
+	}
+	output.WriteString(ins.Strings[len(ins.Strings)-1])
+	output.WriteString("\"")
+
+	return output.String()
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -179,6 +459,7 @@ type Boolean struct {
 
 func (bln *Boolean) expressionNode()      {}
 func (bln *Boolean) TokenLiteral() string { return bln.Token.Literal }
+func (bln *Boolean) Pos() token.Position  { return bln.Token.Pos }
 func (bln *Boolean) String() string       { return bln.Token.Literal }
 
 type IfExpression struct {
@@ -190,6 +471,7 @@ type IfExpression struct {
 
 func (ife *IfExpression) expressionNode()      {}
 func (ife *IfExpression) TokenLiteral() string { return ife.Token.Literal }
+func (ife *IfExpression) Pos() token.Position  { return ife.Token.Pos }
 func (ife *IfExpression) String() string {
 	var output bytes.Buffer
 
@@ -213,6 +495,7 @@ type BlockStatement struct {
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Token.Pos }
 func (bs *BlockStatement) String() string {
 	var output bytes.Buffer
 
@@ -225,24 +508,27 @@ func (bs *BlockStatement) String() string {
 
 type FunctionLiteral struct {
 	Token      token.Token // 'function' token
+	Name       string      // empty for an anonymous function
 	Parameters []*Identifier
-	Body       *BlockStatement
+	// ParameterTypes holds the annotation of each parameter, nil when the
+	// parameter is untyped; ReturnType is nil when no return type is given.
+	ParameterTypes []TypeExpression
+	ReturnType     TypeExpression
+	Body           *BlockStatement
 }
 
 func (fnl *FunctionLiteral) expressionNode()      {}
 func (fnl *FunctionLiteral) TokenLiteral() string { return fnl.Token.Literal }
+func (fnl *FunctionLiteral) Pos() token.Position  { return fnl.Token.Pos }
 func (fnl *FunctionLiteral) String() string {
 	var output bytes.Buffer
 
-	parameters := []string{}
-	for _, p := range fnl.Parameters {
-		parameters = append(parameters, p.String())
-	}
-
 	output.WriteString(fnl.TokenLiteral())
-	output.WriteString("(")
-	output.WriteString(strings.Join(parameters, ", "))
-	output.WriteString(") ")
+	if fnl.Name != "" {
+		output.WriteString(" " + fnl.Name)
+	}
+	output.WriteString(FormatSignature(fnl.Parameters, fnl.ParameterTypes, fnl.ReturnType))
+	output.WriteString(" ")
 	output.WriteString(fnl.Body.String())
 
 	return output.String()
@@ -256,6 +542,12 @@ type CallExpression struct {
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
 func (ce *CallExpression) String() string {
 	var output bytes.Buffer
 
@@ -279,6 +571,7 @@ type ArrayLiteral struct {
 
 func (arl *ArrayLiteral) expressionNode()      {}
 func (arl *ArrayLiteral) TokenLiteral() string { return arl.Token.Literal }
+func (arl *ArrayLiteral) Pos() token.Position  { return arl.Token.Pos }
 func (arl *ArrayLiteral) String() string {
 	var output bytes.Buffer
 
@@ -303,6 +596,12 @@ type IndexExpression struct {
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
 func (ie *IndexExpression) String() string {
 	var output bytes.Buffer
 
@@ -322,6 +621,7 @@ type HashLiteral struct {
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Pos }
 func (hl *HashLiteral) String() string {
 	var output bytes.Buffer
 
@@ -336,3 +636,27 @@ func (hl *HashLiteral) String() string {
 
 	return output.String()
 }
+
+// FormatSignature renders a parameter list with its optional annotations
+// and return type, e.g. "(int a, b): string".
+func FormatSignature(parameters []*Identifier, parameterTypes []TypeExpression, returnType TypeExpression) string {
+	var output bytes.Buffer
+
+	list := []string{}
+	for i, p := range parameters {
+		if i < len(parameterTypes) && parameterTypes[i] != nil {
+			list = append(list, parameterTypes[i].String()+" "+p.String())
+		} else {
+			list = append(list, p.String())
+		}
+	}
+
+	output.WriteString("(")
+	output.WriteString(strings.Join(list, ", "))
+	output.WriteString(")")
+	if returnType != nil {
+		output.WriteString(": " + returnType.String())
+	}
+
+	return output.String()
+}
@@ -0,0 +1,125 @@
+package ast
+
+import (
+	"Go-Tutorials/Core-lang/token"
+	"bytes"
+	"strings"
+)
+
+// TypeExpression is a type annotation as written in the source, such as
+// int, [string], {string: int}, function(int): bool, int? or int | string.
+type TypeExpression interface {
+	Node
+	typeNode()
+}
+
+// NamedType is one of the built-in types int, float, string and bool.
+type NamedType struct {
+	Token token.Token // the type keyword
+	Name  string
+}
+
+func (nt *NamedType) typeNode()            {}
+func (nt *NamedType) TokenLiteral() string { return nt.Token.Literal }
+func (nt *NamedType) Pos() token.Position  { return nt.Token.Pos }
+func (nt *NamedType) String() string       { return nt.Name }
+
+// ArrayType is [Element], an array whose elements all have type Element.
+type ArrayType struct {
+	Token   token.Token // the '[' token
+	Element TypeExpression
+}
+
+func (at *ArrayType) typeNode()            {}
+func (at *ArrayType) TokenLiteral() string { return at.Token.Literal }
+func (at *ArrayType) Pos() token.Position  { return at.Token.Pos }
+func (at *ArrayType) String() string       { return "[" + at.Element.String() + "]" }
+
+// HashType is {Key: Value}.
+type HashType struct {
+	Token token.Token // the '{' token
+	Key   TypeExpression
+	Value TypeExpression
+}
+
+func (ht *HashType) typeNode()            {}
+func (ht *HashType) TokenLiteral() string { return ht.Token.Literal }
+func (ht *HashType) Pos() token.Position  { return ht.Token.Pos }
+func (ht *HashType) String() string {
+	return "{" + ht.Key.String() + ": " + ht.Value.String() + "}"
+}
+
+// FunctionType is function(Parameters): Return. Return is nil when the
+// function type does not declare a result.
+type FunctionType struct {
+	Token      token.Token // the 'function' token
+	Parameters []TypeExpression
+	Return     TypeExpression
+}
+
+func (ft *FunctionType) typeNode()            {}
+func (ft *FunctionType) TokenLiteral() string { return ft.Token.Literal }
+func (ft *FunctionType) Pos() token.Position  { return ft.Token.Pos }
+func (ft *FunctionType) String() string {
+	var output bytes.Buffer
+
+	parameters := []string{}
+	for _, p := range ft.Parameters {
+		parameters = append(parameters, p.String())
+	}
+
+	output.WriteString("function(")
+	output.WriteString(strings.Join(parameters, ", "))
+	output.WriteString(")")
+	if ft.Return != nil {
+		output.WriteString(": " + ft.Return.String())
+	}
+
+	return output.String()
+}
+
+// NullableType is Type?, which also admits null.
+type NullableType struct {
+	Token token.Token // the '?' token
+	Type  TypeExpression
+}
+
+func (nt *NullableType) typeNode()            {}
+func (nt *NullableType) TokenLiteral() string { return nt.Token.Literal }
+func (nt *NullableType) Pos() token.Position  { return nt.Type.Pos() }
+func (nt *NullableType) String() string {
+	if _, ok := nt.Type.(*UnionType); ok || hasReturnType(nt.Type) {
+		return "(" + nt.Type.String() + ")?"
+	}
+	return nt.Type.String() + "?"
+}
+
+// UnionType admits a value of any of its member Types.
+type UnionType struct {
+	Token token.Token // the first '|' token
+	Types []TypeExpression
+}
+
+func (ut *UnionType) typeNode()            {}
+func (ut *UnionType) TokenLiteral() string { return ut.Token.Literal }
+func (ut *UnionType) Pos() token.Position  { return ut.Types[0].Pos() }
+func (ut *UnionType) String() string {
+	types := []string{}
+	for _, t := range ut.Types {
+		if hasReturnType(t) {
+			types = append(types, "("+t.String()+")")
+		} else {
+			types = append(types, t.String())
+		}
+	}
+
+	return strings.Join(types, " | ")
+}
+
+// hasReturnType reports whether t is a function type with a return type,
+// which has to be parenthesized before a trailing "?" or "|" so that these
+// do not become part of the return type.
+func hasReturnType(t TypeExpression) bool {
+	function, ok := t.(*FunctionType)
+	return ok && function.Return != nil
+}
@@ -0,0 +1,31 @@
+package diagnostic
+
+// Error codes reported by the parser.
+const (
+	UnexpectedToken = "E0001" // a different token was expected
+	NoPrefixParse   = "E0002" // token cannot start an expression
+	InvalidInteger  = "E0003" // integer literal could not be parsed
+	InvalidFloat    = "E0005" // float literal could not be parsed
+	InvalidTarget   = "E0006" // left side of an assignment is not assignable
+	OutsideLoop     = "E0007" // break or continue outside of a loop
+)
+
+// Error codes reported by the type checker.
+const (
+	TypeMismatch     = "E0004" // value does not match the declared type
+	InvalidOperation = "E0201" // operator not defined for its operand types
+	ArgumentCount    = "E0202" // call with the wrong number of arguments
+	NotCallable      = "E0203" // called value is not a function
+	NotIterable      = "E0204" // for-in over a value that cannot be iterated
+	NotIndexable     = "E0205" // index operator not supported by the value
+	InvalidHashKey   = "E0206" // hash key type cannot be hashed
+)
+
+// Error codes reported by the lexer.
+const (
+	UnterminatedComment = "E0101" // block comment missing its closing */
+	InvalidEncoding     = "E0102" // input is not valid UTF-8
+	UnterminatedString  = "E0103" // string literal missing its closing quote
+	InvalidEscape       = "E0104" // unknown or malformed escape sequence
+	InvalidNumber       = "E0105" // malformed number literal
+)
@@ -0,0 +1,165 @@
+package diagnostic
+
+import (
+	"Go-Tutorials/Core-lang/token"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Note:
+		return "note"
+	default:
+		return "unknown"
+	}
+}
+
+// Span is the region of source a diagnostic refers to. End is the position
+// immediately after the last character of the region.
+type Span struct {
+	Start token.Position
+	End   token.Position
+}
+
+// TokenSpan returns the span covered by a single token.
+func TokenSpan(tok token.Token) Span {
+	return Span{Start: tok.Pos, End: tok.End}
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Span     Span
+	Message  string
+	Hints    []string
+}
+
+// New creates an error diagnostic with a formatted message.
+func New(code string, span Span, format string, a ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Severity: Error,
+		Code:     code,
+		Span:     span,
+		Message:  fmt.Sprintf(format, a...),
+	}
+}
+
+// WithHint appends a hint to the diagnostic and returns it.
+func (d *Diagnostic) WithHint(format string, a ...interface{}) *Diagnostic {
+	d.Hints = append(d.Hints, fmt.Sprintf(format, a...))
+	return d
+}
+
+// Error formats the diagnostic on a single line, e.g.
+// "main.core:2:11: error[E0001]: expected ...".
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s: %s", d.Span.Start, d.header(), d.Message)
+}
+
+func (d *Diagnostic) header() string {
+	if d.Code == "" {
+		return d.Severity.String()
+	}
+	return fmt.Sprintf("%s[%s]", d.Severity, d.Code)
+}
+
+// Render formats the diagnostic together with the offending line of source,
+// underlining the span with carets:
+//
+//	error[E0001]: expected next token to be - =, got - INT instead
+//	 --> main.core:2:11
+//	  |
+//	2 | var int b 6;
+//	  |           ^
+//	  = hint: ...
+func Render(source string, d *Diagnostic) string {
+	var output bytes.Buffer
+
+	output.WriteString(d.header() + ": " + d.Message + "\n")
+
+	start := d.Span.Start
+	if !start.IsValid() {
+		for _, hint := range d.Hints {
+			output.WriteString("  = hint: " + hint + "\n")
+		}
+		return output.String()
+	}
+
+	lineText, ok := sourceLine(source, start.Line)
+	gutter := strings.Repeat(" ", len(fmt.Sprintf("%d", start.Line)))
+
+	output.WriteString(gutter + "--> " + start.String() + "\n")
+
+	if ok {
+		output.WriteString(gutter + " |\n")
+		output.WriteString(fmt.Sprintf("%d | %s\n", start.Line, lineText))
+		output.WriteString(gutter + " | " + underline(lineText, start, d.Span.End) + "\n")
+	}
+
+	for _, hint := range d.Hints {
+		output.WriteString(gutter + " = hint: " + hint + "\n")
+	}
+
+	return output.String()
+}
+
+// RenderAll renders every diagnostic, separated by blank lines.
+func RenderAll(source string, diagnostics []*Diagnostic) string {
+	rendered := []string{}
+	for _, d := range diagnostics {
+		rendered = append(rendered, Render(source, d))
+	}
+	return strings.Join(rendered, "\n")
+}
+
+func sourceLine(source string, line int) (string, bool) {
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// underline builds the caret line for a span starting on lineText. Columns
+// count runes, and tabs before the span are kept so the carets line up with
+// the source.
+func underline(lineText string, start, end token.Position) string {
+	var output bytes.Buffer
+
+	chars := []rune(lineText)
+	startIndex := start.Column - 1
+	if startIndex > len(chars) {
+		startIndex = len(chars)
+	}
+	for _, char := range chars[:startIndex] {
+		if char == '\t' {
+			output.WriteByte('\t')
+		} else {
+			output.WriteByte(' ')
+		}
+	}
+
+	width := 1
+	if end.Line == start.Line && end.Column > start.Column {
+		width = end.Column - start.Column
+	} else if end.Line > start.Line && len(chars) > startIndex {
+		width = len(chars) - startIndex
+	}
+	output.WriteString(strings.Repeat("^", width))
+
+	return output.String()
+}
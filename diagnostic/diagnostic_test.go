@@ -0,0 +1,73 @@
+package diagnostic
+
+import (
+	"Go-Tutorials/Core-lang/token"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	diag := New(UnexpectedToken, Span{
+		Start: token.Position{Filename: "main.core", Line: 2, Column: 11},
+	}, "expected next token to be - %s", "=")
+
+	expected := "main.core:2:11: error[E0001]: expected next token to be - ="
+	if diag.Error() != expected {
+		t.Errorf("diag.Error() wrong. expected=%q, got=%q", expected, diag.Error())
+	}
+}
+
+func TestRender(t *testing.T) {
+	source := "var int a = 5;\n\tvar string b = 6;\ngröße + ) 1\n"
+
+	tests := []struct {
+		diag     *Diagnostic
+		expected string
+	}{
+		{
+			New(TypeMismatch, Span{
+				Start: token.Position{Line: 2, Column: 17},
+				End:   token.Position{Line: 2, Column: 18},
+			}, "type mismatch: cannot assign int to string variable").
+				WithHint("declare the variable as int or change the assigned value"),
+			"error[E0004]: type mismatch: cannot assign int to string variable\n" +
+				" --> 2:17\n" +
+				"  |\n" +
+				"2 | \tvar string b = 6;\n" +
+				"  | \t               ^\n" +
+				"  = hint: declare the variable as int or change the assigned value\n",
+		},
+		{
+			New(UnexpectedToken, Span{
+				Start: token.Position{Filename: "main.core", Line: 1, Column: 5},
+				End:   token.Position{Filename: "main.core", Line: 1, Column: 8},
+			}, "unexpected int"),
+			"error[E0001]: unexpected int\n" +
+				" --> main.core:1:5\n" +
+				"  |\n" +
+				"1 | var int a = 5;\n" +
+				"  |     ^^^\n",
+		},
+		{
+			New(NoPrefixParse, Span{
+				Start: token.Position{Line: 3, Column: 9},
+				End:   token.Position{Line: 3, Column: 10},
+			}, "no prefix parse function for ) found"),
+			"error[E0002]: no prefix parse function for ) found\n" +
+				" --> 3:9\n" +
+				"  |\n" +
+				"3 | größe + ) 1\n" +
+				"  |         ^\n",
+		},
+		{
+			&Diagnostic{Severity: Warning, Message: "no position"},
+			"warning: no position\n",
+		},
+	}
+
+	for i, tt := range tests {
+		rendered := Render(source, tt.diag)
+		if rendered != tt.expected {
+			t.Errorf("tests[%d] - Render wrong.\nexpected:\n%s\ngot:\n%s", i, tt.expected, rendered)
+		}
+	}
+}
@@ -1,5 +1,130 @@
 package evaluator
 
-import "Go-Tutorials/Core-lang/object"
+import (
+	"Go-Tutorials/Core-lang/object"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
 
-var builtins = map[string]*object.Builtin{}
+var builtins = map[string]*object.Builtin{
+	// len returns the number of elements of an array or hash, or the number
+	// of Unicode code points (not bytes) in a string.
+	"len": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.String:
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Pairs))}
+			default:
+				return newError("argument to `len` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	// int converts a float (truncating toward zero) or a numeric string of
+	// any size to an integer.
+	"int": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInteger:
+				return arg
+			case *object.Float:
+				if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+					return newError("cannot convert %s to int", arg.Inspect())
+				}
+				value, _ := big.NewFloat(arg.Value).Int(nil)
+				return object.NewBigInteger(value)
+			case *object.String:
+				value, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 10)
+				if !ok {
+					return newError("cannot convert %q to int", arg.Value)
+				}
+				return object.NewBigInteger(value)
+			default:
+				return newError("argument to `int` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	// float converts an integer or a numeric string to a float.
+	"float": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInteger:
+				return &object.Float{Value: toFloat(arg)}
+			case *object.Float:
+				return arg
+			case *object.String:
+				value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return newError("cannot convert %q to float", arg.Value)
+				}
+				return &object.Float{Value: value}
+			default:
+				return newError("argument to `float` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	// range returns the integers from start (default 0) up to but excluding
+	// end, counting by step (default 1), for use in for-in loops.
+	"range": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=1..3", len(args))
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				if _, ok := arg.(*object.BigInteger); ok {
+					return newError("range bound %s does not fit in int64", arg.Inspect())
+				}
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("argument to `range` not supported, got %s", arg.Type())
+				}
+				bounds[i] = integer.Value
+			}
+
+			switch len(bounds) {
+			case 1:
+				return &object.Range{Start: 0, End: bounds[0], Step: 1}
+			case 2:
+				return &object.Range{Start: bounds[0], End: bounds[1], Step: 1}
+			default:
+				if bounds[2] == 0 {
+					return newError("range step must not be zero")
+				}
+				return &object.Range{Start: bounds[0], End: bounds[1], Step: bounds[2]}
+			}
+		},
+	},
+	// string returns the printed form of any value.
+	"string": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+}
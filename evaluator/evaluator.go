@@ -3,17 +3,33 @@ package evaluator
 import (
 	"Go-Tutorials/Core-lang/ast"
 	"Go-Tutorials/Core-lang/object"
+	"bytes"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
 )
 
 func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// maxShiftCount bounds left shifts, and the size of powers in bits, so that
+// a script cannot allocate an arbitrarily large integer with a single
+// operator.
+const maxShiftCount = 1 << 24
+
+// maxCallDepth bounds the nesting of function calls. Unbounded recursion
+// would otherwise exhaust the Go stack, which recover cannot catch.
+const maxCallDepth = 10000
+
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
 func Evaluate(node ast.Node, env *object.Environment) object.Object {
@@ -40,18 +56,48 @@ func Evaluate(node ast.Node, env *object.Environment) object.Object {
 		if isError(value) {
 			return value
 		}
-		env.Set(node.Name.Value, value)
+		if !matchesType(node.Type, value) {
+			return variableTypeMismatch(node.Name.Value, node.Type, value)
+		}
+		env.Declare(node.Name.Value, value, node.Type)
+
+	case *ast.WhileStatement:
+		return evaluateWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return evaluateForStatement(node, env)
+
+	case *ast.ForInStatement:
+		return evaluateForInStatement(node, env)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
 
 	// Expressions
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInteger{Value: node.Big}
+		}
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
+	case *ast.NullLiteral:
+		return NULL
+
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.InterpolatedString:
+		return evaluateInterpolatedString(node, env)
+
 	case *ast.PrefixExpression:
 		right := Evaluate(node.Right, env)
 		if isError(right) {
@@ -70,6 +116,15 @@ func Evaluate(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evaluateInfixExpression(node.Operator, left, right)
 
+	case *ast.AssignExpression:
+		return evaluateAssignExpression(node, env)
+
+	case *ast.UpdateExpression:
+		return evaluateUpdateExpression(node, env)
+
+	case *ast.LogicalExpression:
+		return evaluateLogicalExpression(node, env)
+
 	case *ast.IfExpression:
 		return evaluateIfExpression(node, env)
 
@@ -77,9 +132,11 @@ func Evaluate(node ast.Node, env *object.Environment) object.Object {
 		return evaluateIdentifier(node, env)
 
 	case *ast.FunctionLiteral:
-		parameters := node.Parameters
-		body := node.Body
-		return &object.Function{Parameters: parameters, Env: env, Body: body}
+		return newFunction(node, env)
+
+	case *ast.FunctionStatement:
+		// already bound by hoistFunctions when the enclosing block started
+		return nil
 
 	case *ast.CallExpression:
 		function := Evaluate(node.Function, env)
@@ -111,13 +168,25 @@ func Evaluate(node ast.Node, env *object.Environment) object.Object {
 			return index
 		}
 		return evaluateIndexExpression(left, index)
+
+	case *ast.HashLiteral:
+		return evaluateHashLiteral(node, env)
 	}
 
 	return nil
 }
 
-func evaluateStatements(program *ast.Program, env *object.Environment) object.Object {
-	var result object.Object
+// evaluateStatements runs a whole program. A Go panic anywhere in the
+// evaluation is turned into an error value, and applyFunction limits the
+// depth of recursion, so that no script can bring down the host process.
+func evaluateStatements(program *ast.Program, env *object.Environment) (result object.Object) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = newError("internal error: %v", recovered)
+		}
+	}()
+
+	hoistFunctions(program.Statements, env)
 
 	for _, statement := range program.Statements {
 		result = Evaluate(statement, env)
@@ -146,8 +215,10 @@ func evaluatePrefixExpression(operator string, right object.Object) object.Objec
 		return evaluateBangOperatorExpression(right)
 	case "-":
 		return evaluateMinusPrefixOperatorExpression(right)
+	case "~":
+		return evaluateBitwiseNotOperatorExpression(right)
 	default:
-		return NULL
+		return newError("unknown operator: %s%s", operator, right.Type())
 	}
 }
 
@@ -166,59 +237,372 @@ func evaluateBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evaluateMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
-		return NULL
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.NewBigInteger(new(big.Int).Neg(toBigInt(right)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInteger:
+		return object.NewBigInteger(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError("unknown operator: -%s", right.Type())
 	}
+}
 
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
+// evaluateBitwiseNotOperatorExpression complements an integer; for any
+// integer x, ~x == -x - 1.
+func evaluateBitwiseNotOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: ^right.Value}
+	case *object.BigInteger:
+		return object.NewBigInteger(new(big.Int).Not(right.Value))
+	default:
+		return newError("unknown operator: ~%s", right.Type())
+	}
 }
 
+// evaluateInfixExpression applies a binary operator according to this matrix:
+//
+//	INTEGER op INTEGER  + - * / % ** < > <= >= == !=  (math/big on overflow)
+//	                    & | ^ << >>  (two's complement, arithmetic >>)
+//	INTEGER op FLOAT    the integer is converted, then FLOAT rules apply
+//	FLOAT op FLOAT      + - * / % ** < > <= >= == !=
+//	STRING op STRING    + concatenates, < > <= >= == != compare by code point
+//	BOOLEAN op BOOLEAN  == !=
+//	NULL op NULL        == !=
+//
+// == and != accept any pair of values: values of different types are never
+// equal, and arrays, hashes and functions are only equal to themselves.
+// Every other combination is an error, "type mismatch" when the operand
+// types differ and "unknown operator" when they agree.
 func evaluateInfixExpression(
 	operator string,
 	left, right object.Object,
 ) object.Object {
-	// fmt.Printf("Operator: %s, Left: %v, Right: %v\n", operator, left, right)
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		result := evaluateIntegerInfixExpression(operator, left, right)
-		// fmt.Printf("Intermediate result: %v\n", result)
-		return result
+		return evaluateIntegerInfixExpression(operator, left, right)
+	case isNumeric(left) && isNumeric(right) && !isBitwiseOperator(operator):
+		// mixing an integer with a float converts the integer to float
+		return evaluateFloatInfixExpression(operator, toFloat(left), toFloat(right))
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evaluateStringInfixExpression(operator, left, right)
+	case operator == "==":
+		return nativeBoolToBooleanObject(objectsEqual(left, right))
+	case operator == "!=":
+		return nativeBoolToBooleanObject(!objectsEqual(left, right))
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
-		return NULL
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// objectsEqual compares booleans and null by value and everything else that
+// reaches it (arrays, hashes, functions) by identity.
+func objectsEqual(left, right object.Object) bool {
+	if left.Type() != right.Type() {
+		return false
+	}
+
+	switch left := left.(type) {
+	case *object.Boolean:
+		return left.Value == right.(*object.Boolean).Value
+	case *object.Null:
+		return true
+	default:
+		return left == right
+	}
+}
+
+func evaluateStringInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftValue := left.(*object.String).Value
+	rightValue := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftValue + rightValue}
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// evaluateIntegerInfixExpression computes with int64 values while the
+// result fits and switches to math/big when an operand is already a
+// BigInteger or the int64 result would overflow.
 func evaluateIntegerInfixExpression(
 	operator string,
 	left, right object.Object,
 ) object.Object {
-	leftValue := left.(*object.Integer).Value
-	rightValue := right.(*object.Integer).Value
-	// fmt.Printf("Left value: %d, Right value: %d\n", leftValue, rightValue)
+	leftInteger, leftSmall := left.(*object.Integer)
+	rightInteger, rightSmall := right.(*object.Integer)
+
+	if leftSmall && rightSmall {
+		if result, ok := evaluateSmallIntegerInfixExpression(operator, leftInteger.Value, rightInteger.Value); ok {
+			return result
+		}
+	}
+
+	return evaluateBigIntegerInfixExpression(operator, toBigInt(left), toBigInt(right))
+}
+
+// evaluateSmallIntegerInfixExpression reports ok=false when the result does
+// not fit in an int64.
+func evaluateSmallIntegerInfixExpression(
+	operator string,
+	leftValue, rightValue int64,
+) (object.Object, bool) {
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftValue + rightValue}
+		sum := leftValue + rightValue
+		return &object.Integer{Value: sum}, (sum > leftValue) == (rightValue > 0)
 	case "-":
-		return &object.Integer{Value: leftValue - rightValue}
+		difference := leftValue - rightValue
+		return &object.Integer{Value: difference}, (difference < leftValue) == (rightValue > 0)
 	case "*":
-		return &object.Integer{Value: leftValue * rightValue}
+		if leftValue == 0 || rightValue == 0 {
+			return &object.Integer{Value: 0}, true
+		}
+		product := leftValue * rightValue
+		overflow := product/rightValue != leftValue ||
+			leftValue == -1 && rightValue == math.MinInt64 ||
+			rightValue == -1 && leftValue == math.MinInt64
+		return &object.Integer{Value: product}, !overflow
 	case "/":
-		return &object.Integer{Value: leftValue / rightValue}
+		if rightValue == 0 {
+			return newError("division by zero"), true
+		}
+		if leftValue == math.MinInt64 && rightValue == -1 {
+			return nil, false
+		}
+		return &object.Integer{Value: leftValue / rightValue}, true
+	case "%":
+		if rightValue == 0 {
+			return newError("modulo by zero"), true
+		}
+		return &object.Integer{Value: leftValue % rightValue}, true
+	case "**":
+		// computed with math/big, which normalizes small results back
+		return nil, false
+	case "&":
+		return &object.Integer{Value: leftValue & rightValue}, true
+	case "|":
+		return &object.Integer{Value: leftValue | rightValue}, true
+	case "^":
+		return &object.Integer{Value: leftValue ^ rightValue}, true
+	case "<<":
+		// may overflow, and checking that is no cheaper than math/big
+		return nil, false
+	case ">>":
+		if rightValue < 0 {
+			return newError("negative shift count: %d", rightValue), true
+		}
+		if rightValue > 63 {
+			rightValue = 63
+		}
+		return &object.Integer{Value: leftValue >> uint(rightValue)}, true
 	default:
-		return NULL
+		return evaluateIntegerComparison(operator, leftValue, rightValue), true
+	}
+}
+
+func evaluateIntegerComparison(
+	operator string,
+	leftValue, rightValue int64,
+) object.Object {
+	switch operator {
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: INTEGER %s INTEGER", operator)
+	}
+}
+
+func evaluateBigIntegerInfixExpression(
+	operator string,
+	leftValue, rightValue *big.Int,
+) object.Object {
+	switch operator {
+	case "+":
+		return object.NewBigInteger(new(big.Int).Add(leftValue, rightValue))
+	case "-":
+		return object.NewBigInteger(new(big.Int).Sub(leftValue, rightValue))
+	case "*":
+		return object.NewBigInteger(new(big.Int).Mul(leftValue, rightValue))
+	case "/":
+		if rightValue.Sign() == 0 {
+			return newError("division by zero")
+		}
+		// Quo truncates toward zero like int64 division
+		return object.NewBigInteger(new(big.Int).Quo(leftValue, rightValue))
+	case "%":
+		if rightValue.Sign() == 0 {
+			return newError("modulo by zero")
+		}
+		// Rem takes the sign of the dividend like int64 %
+		return object.NewBigInteger(new(big.Int).Rem(leftValue, rightValue))
+	case "**":
+		if rightValue.Sign() < 0 {
+			return newError("negative exponent: %s", rightValue)
+		}
+		// the result has about rightValue * BitLen bits; 0, 1 and -1 stay small
+		if bits := leftValue.BitLen(); bits > 1 {
+			size := new(big.Int).Mul(rightValue, big.NewInt(int64(bits)))
+			if size.Cmp(big.NewInt(maxShiftCount)) > 0 {
+				return newError("exponent too large: %s", rightValue)
+			}
+		}
+		return object.NewBigInteger(new(big.Int).Exp(leftValue, rightValue, nil))
+	case "&":
+		return object.NewBigInteger(new(big.Int).And(leftValue, rightValue))
+	case "|":
+		return object.NewBigInteger(new(big.Int).Or(leftValue, rightValue))
+	case "^":
+		return object.NewBigInteger(new(big.Int).Xor(leftValue, rightValue))
+	case "<<", ">>":
+		return evaluateShift(operator, leftValue, rightValue)
+	default:
+		return evaluateIntegerComparison(operator, int64(leftValue.Cmp(rightValue)), 0)
+	}
+}
+
+func evaluateShift(operator string, leftValue, count *big.Int) object.Object {
+	if count.Sign() < 0 {
+		return newError("negative shift count: %s", count)
+	}
+
+	if operator == ">>" {
+		// shifting by at least the bit length leaves only the sign
+		if count.Cmp(big.NewInt(int64(leftValue.BitLen()))) > 0 {
+			count = big.NewInt(int64(leftValue.BitLen()))
+		}
+		return object.NewBigInteger(new(big.Int).Rsh(leftValue, uint(count.Uint64())))
+	}
+
+	if !count.IsUint64() || count.Uint64() > maxShiftCount {
+		return newError("shift count too large: %s", count)
+	}
+	return object.NewBigInteger(new(big.Int).Lsh(leftValue, uint(count.Uint64())))
+}
+
+func evaluateFloatInfixExpression(
+	operator string,
+	leftValue, rightValue float64,
+) object.Object {
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftValue + rightValue}
+	case "-":
+		return &object.Float{Value: leftValue - rightValue}
+	case "*":
+		return &object.Float{Value: leftValue * rightValue}
+	case "/":
+		if rightValue == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: leftValue / rightValue}
+	case "%":
+		if rightValue == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Float{Value: math.Mod(leftValue, rightValue)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftValue, rightValue)}
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "<=":
+		return nativeBoolToBooleanObject(leftValue <= rightValue)
+	case ">=":
+		return nativeBoolToBooleanObject(leftValue >= rightValue)
+	case "==":
+		return nativeBoolToBooleanObject(leftValue == rightValue)
+	case "!=":
+		return nativeBoolToBooleanObject(leftValue != rightValue)
+	default:
+		return newError("unknown operator: FLOAT %s FLOAT", operator)
+	}
+}
+
+func isBitwiseOperator(operator string) bool {
+	switch operator {
+	case "&", "|", "^", "<<", ">>":
+		return true
+	default:
+		return false
+	}
+}
+
+func isNumeric(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInteger:
+		value, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return value
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
+// toBigInt converts either integer representation to a *big.Int.
+func toBigInt(obj object.Object) *big.Int {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return big.NewInt(obj.Value)
+	case *object.BigInteger:
+		return obj.Value
+	default:
+		return new(big.Int)
 	}
 }
 
 func evaluateBlockStatement(block *ast.BlockStatement, environment *object.Environment) object.Object {
 	var result object.Object
 
+	hoistFunctions(block.Statements, environment)
+
 	for _, statement := range block.Statements {
 		result = Evaluate(statement, environment)
-		fmt.Printf("Evaluated: %#v\n", result)
 		if result != nil {
-			returnType := result.Type()
-			if returnType == object.RETURN_VALUE_OBJ || returnType == object.ERROR_OBJ {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
 				return result
 			}
 		}
@@ -227,6 +611,187 @@ func evaluateBlockStatement(block *ast.BlockStatement, environment *object.Envir
 	return result
 }
 
+// evaluateWhileStatement runs the body until the condition becomes falsy.
+// Loops are statements and produce no value.
+func evaluateWhileStatement(
+	node *ast.WhileStatement,
+	env *object.Environment,
+) object.Object {
+	for {
+		condition := Evaluate(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		result := Evaluate(node.Body, env)
+		if stop, signal := loopControl(result); stop {
+			return signal
+		}
+	}
+}
+
+// evaluateForStatement scopes the init statement to the loop, so a counter
+// declared there is not visible after it.
+func evaluateForStatement(
+	node *ast.ForStatement,
+	env *object.Environment,
+) object.Object {
+	loopEnv := object.NewEnclosedEnvironment(env)
+
+	if node.Init != nil {
+		if init := Evaluate(node.Init, loopEnv); isError(init) {
+			return init
+		}
+	}
+
+	for {
+		if node.Condition != nil {
+			condition := Evaluate(node.Condition, loopEnv)
+			if isError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				return nil
+			}
+		}
+
+		result := Evaluate(node.Body, loopEnv)
+		if stop, signal := loopControl(result); stop {
+			return signal
+		}
+
+		if node.Post != nil {
+			if post := Evaluate(node.Post, loopEnv); isError(post) {
+				return post
+			}
+		}
+	}
+}
+
+// evaluateForInStatement walks arrays and ranges in order, strings by code
+// point and hashes in the order of Hash.SortedPairs. Every iteration binds
+// the loop variables in a fresh scope, so closures created in the body keep
+// the values of their own iteration.
+func evaluateForInStatement(
+	node *ast.ForInStatement,
+	env *object.Environment,
+) object.Object {
+	iterable := Evaluate(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	var signal object.Object
+	iterate := func(key, value object.Object) bool {
+		iterationEnv := object.NewEnclosedEnvironment(env)
+		if node.Key != nil {
+			iterationEnv.Set(node.Key.Value, key)
+		}
+		iterationEnv.Set(node.Value.Value, value)
+
+		var stop bool
+		stop, signal = loopControl(Evaluate(node.Body, iterationEnv))
+		return !stop
+	}
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		for i, element := range iterable.Elements {
+			if !iterate(&object.Integer{Value: int64(i)}, element) {
+				break
+			}
+		}
+
+	case *object.String:
+		i := 0
+		for _, char := range iterable.Value {
+			if !iterate(&object.Integer{Value: int64(i)}, &object.String{Value: string(char)}) {
+				break
+			}
+			i++
+		}
+
+	case *object.Hash:
+		for _, pair := range iterable.SortedPairs() {
+			value := pair.Value
+			if node.Key == nil {
+				value = pair.Key
+			}
+			if !iterate(pair.Key, value) {
+				break
+			}
+		}
+
+	case *object.Range:
+		var i int64
+		for value := iterable.Start; rangeContinues(iterable, value); value += iterable.Step {
+			if !iterate(&object.Integer{Value: i}, &object.Integer{Value: value}) {
+				break
+			}
+			if iterable.Step > 0 && value > math.MaxInt64-iterable.Step ||
+				iterable.Step < 0 && value < math.MinInt64-iterable.Step {
+				break // the next value would overflow, so it is past End
+			}
+			i++
+		}
+
+	default:
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+
+	return signal
+}
+
+func rangeContinues(r *object.Range, value int64) bool {
+	if r.Step > 0 {
+		return value < r.End
+	}
+	return value > r.End
+}
+
+// loopControl inspects the result of one loop iteration. It reports whether
+// the loop has to stop and, if so, what the loop statement evaluates to: a
+// return value or error keeps unwinding, a break is consumed.
+func loopControl(result object.Object) (bool, object.Object) {
+	if result == nil {
+		return false, nil
+	}
+
+	switch result.Type() {
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+		return true, result
+	case object.BREAK_OBJ:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// hoistFunctions binds every function declared directly in statements
+// before any of them runs, so declarations can call each other regardless
+// of their order.
+func hoistFunctions(statements []ast.Statement, env *object.Environment) {
+	for _, statement := range statements {
+		if declaration, ok := statement.(*ast.FunctionStatement); ok {
+			env.Set(declaration.Name.Value, newFunction(declaration.Function, env))
+		}
+	}
+}
+
+func newFunction(node *ast.FunctionLiteral, env *object.Environment) *object.Function {
+	return &object.Function{
+		Name:           node.Name,
+		Parameters:     node.Parameters,
+		ParameterTypes: node.ParameterTypes,
+		ReturnType:     node.ReturnType,
+		Env:            env,
+		Body:           node.Body,
+	}
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -252,6 +817,229 @@ func evaluateIfExpression(
 	}
 }
 
+func evaluateAssignExpression(
+	node *ast.AssignExpression,
+	env *object.Environment,
+) object.Object {
+	value := Evaluate(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	if node.Operator == "=" {
+		return store(node.Target, env, func(object.Object) object.Object {
+			return value
+		})
+	}
+
+	// "+=" applies "+" to the current value, and so on
+	operator := node.Operator[:len(node.Operator)-1]
+	return store(node.Target, env, func(current object.Object) object.Object {
+		return evaluateInfixExpression(operator, current, value)
+	})
+}
+
+func evaluateUpdateExpression(
+	node *ast.UpdateExpression,
+	env *object.Environment,
+) object.Object {
+	var old object.Object
+	updated := store(node.Target, env, func(current object.Object) object.Object {
+		old = current
+		return evaluateInfixExpression(node.Operator[:1], current, &object.Integer{Value: 1})
+	})
+
+	if node.Prefix || isError(updated) {
+		return updated
+	}
+	return old
+}
+
+// store computes a new value for an assignment target from its current one
+// and writes it back, returning the stored value. The collection and index
+// of an index target are evaluated exactly once.
+func store(
+	target ast.Expression,
+	env *object.Environment,
+	update func(current object.Object) object.Object,
+) object.Object {
+	switch target := target.(type) {
+	case *ast.Identifier:
+		current, ok := env.Get(target.Value)
+		if !ok {
+			return newError("assignment to undeclared variable: %s", target.Value)
+		}
+
+		value := update(current)
+		if isError(value) {
+			return value
+		}
+		if declared := env.DeclaredType(target.Value); !matchesType(declared, value) {
+			return variableTypeMismatch(target.Value, declared, value)
+		}
+		env.Assign(target.Value, value)
+		return value
+
+	case *ast.IndexExpression:
+		left := Evaluate(target.Left, env)
+		if isError(left) {
+			return left
+		}
+
+		index := Evaluate(target.Index, env)
+		if isError(index) {
+			return index
+		}
+
+		// the element is checked against the declared type of the collection
+		// before it is stored; when that type is not a plain array or hash
+		// type, the whole variable is checked afterwards instead
+		name, container := declaredContainerType(target.Left, env)
+		if nullable, ok := container.(*ast.NullableType); ok {
+			container = nullable.Type
+		}
+		value := storeIndex(left, index, func(current object.Object) object.Object {
+			value := update(current)
+			if isError(value) {
+				return value
+			}
+			return checkElement(name, container, index, value)
+		})
+
+		switch container.(type) {
+		case *ast.ArrayType, *ast.HashType:
+		default:
+			if declared := env.DeclaredType(name); !isError(value) && declared != nil {
+				if root, _ := env.Get(name); !matchesType(declared, root) {
+					return variableTypeMismatch(name, declared, root)
+				}
+			}
+		}
+		return value
+
+	default:
+		return newError("invalid assignment target: %s", target.String())
+	}
+}
+
+// declaredContainerType returns the variable at the root of an index
+// expression such as a[1][2] and the type declared for the collection the
+// expression denotes, or nil if that type is unknown.
+func declaredContainerType(
+	node ast.Expression,
+	env *object.Environment,
+) (string, ast.TypeExpression) {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		return node.Value, env.DeclaredType(node.Value)
+	case *ast.IndexExpression:
+		name, container := declaredContainerType(node.Left, env)
+		if nullable, ok := container.(*ast.NullableType); ok {
+			container = nullable.Type
+		}
+		switch container := container.(type) {
+		case *ast.ArrayType:
+			return name, container.Element
+		case *ast.HashType:
+			return name, container.Value
+		default:
+			return name, nil
+		}
+	default:
+		return "", nil
+	}
+}
+
+func checkElement(name string, container ast.TypeExpression, index, value object.Object) object.Object {
+	switch container := container.(type) {
+	case *ast.ArrayType:
+		if !matchesType(container.Element, value) {
+			return newError("type mismatch: element of %s declared %s, got %s",
+				name, container.Element, typeName(value))
+		}
+	case *ast.HashType:
+		if !matchesType(container.Key, index) {
+			return newError("type mismatch: key of %s declared %s, got %s",
+				name, container.Key, typeName(index))
+		}
+		if !matchesType(container.Value, value) {
+			return newError("type mismatch: element of %s declared %s, got %s",
+				name, container.Value, typeName(value))
+		}
+	}
+	return value
+}
+
+func storeIndex(
+	left, index object.Object,
+	update func(current object.Object) object.Object,
+) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		integer, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		if integer.Value < 0 || integer.Value >= int64(len(left.Elements)) {
+			return newError("index out of range: %d with length %d", integer.Value, len(left.Elements))
+		}
+
+		value := update(left.Elements[integer.Value])
+		if isError(value) {
+			return value
+		}
+		left.Elements[integer.Value] = value
+		return value
+
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("Unusable as hash key: %s", index.Type())
+		}
+
+		var current object.Object = NULL
+		if pair, ok := left.Pairs[key.HashKey()]; ok {
+			current = pair.Value
+		}
+
+		value := update(current)
+		if isError(value) {
+			return value
+		}
+		left.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: value}
+		return value
+
+	default:
+		return newError("Index assignment not supported: %s", left.Type())
+	}
+}
+
+// evaluateLogicalExpression only evaluates the right operand when the left
+// one does not already decide the result. The result is always a boolean.
+func evaluateLogicalExpression(
+	node *ast.LogicalExpression,
+	env *object.Environment,
+) object.Object {
+	left := Evaluate(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	switch {
+	case node.Operator == "&&" && !isTruthy(left):
+		return FALSE
+	case node.Operator == "||" && isTruthy(left):
+		return TRUE
+	}
+
+	right := Evaluate(node.Right, env)
+	if isError(right) {
+		return right
+	}
+
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -300,9 +1088,38 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 
 	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newError("wrong number of arguments%s: want=%d, got=%d",
+				functionName(fn), len(fn.Parameters), len(args))
+		}
+		for i, arg := range args {
+			if i < len(fn.ParameterTypes) && !matchesType(fn.ParameterTypes[i], arg) {
+				return newError("type mismatch: parameter %s%s expects %s, got %s",
+					fn.Parameters[i].Value, functionName(fn), fn.ParameterTypes[i], typeName(arg))
+			}
+		}
+
+		if !fn.Env.EnterCall(maxCallDepth) {
+			return newError("maximum call depth of %d exceeded", maxCallDepth)
+		}
+		defer fn.Env.LeaveCall()
+
 		extendedEnv := extendFunctionEnv(fn, args)
-		evaulated := Evaluate(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaulated)
+		evaulated := unwrapReturnValue(Evaluate(fn.Body, extendedEnv))
+		if evaulated == nil {
+			// a body that is empty or ends in a statement without a value
+			evaulated = NULL
+		}
+
+		if fn.ReturnType != nil && !isError(evaulated) && !matchesType(fn.ReturnType, evaulated) {
+			name := "function"
+			if fn.Name != "" {
+				name = "`" + fn.Name + "`"
+			}
+			return newError("type mismatch: %s returned %s, declared %s",
+				name, typeName(evaulated), fn.ReturnType)
+		}
+		return evaulated
 
 	case *object.Builtin:
 		return fn.Fn(args...)
@@ -313,6 +1130,108 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 
 }
 
+// functionName describes fn for error messages, e.g. " to `add`", and is
+// empty for anonymous functions.
+func functionName(fn *object.Function) string {
+	if fn.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" to `%s`", fn.Name)
+}
+
+// matchesType reports whether value belongs to the declared type. A nil
+// declaration accepts every value. Arrays and hashes are checked element by
+// element; a function matches a function type when it has the same number
+// of parameters and any annotations it carries are the declared ones.
+func matchesType(declared ast.TypeExpression, value object.Object) bool {
+	switch declared := declared.(type) {
+	case nil:
+		return true
+	case *ast.NamedType:
+		return typeName(value) == declared.Name
+	case *ast.NullableType:
+		return value == nil || value == NULL || matchesType(declared.Type, value)
+	case *ast.UnionType:
+		for _, member := range declared.Types {
+			if matchesType(member, value) {
+				return true
+			}
+		}
+		return false
+	case *ast.ArrayType:
+		array, ok := value.(*object.Array)
+		if !ok {
+			return false
+		}
+		for _, element := range array.Elements {
+			if !matchesType(declared.Element, element) {
+				return false
+			}
+		}
+		return true
+	case *ast.HashType:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return false
+		}
+		for _, pair := range hash.Pairs {
+			if !matchesType(declared.Key, pair.Key) || !matchesType(declared.Value, pair.Value) {
+				return false
+			}
+		}
+		return true
+	case *ast.FunctionType:
+		return matchesFunctionType(declared, value)
+	default:
+		return false
+	}
+}
+
+func matchesFunctionType(declared *ast.FunctionType, value object.Object) bool {
+	switch fn := value.(type) {
+	case *object.Builtin:
+		return true // builtins check their own arguments
+	case *object.Function:
+		if len(fn.Parameters) != len(declared.Parameters) {
+			return false
+		}
+		for i, parameterType := range fn.ParameterTypes {
+			if parameterType != nil && parameterType.String() != declared.Parameters[i].String() {
+				return false
+			}
+		}
+		if fn.ReturnType != nil && declared.Return != nil {
+			return fn.ReturnType.String() == declared.Return.String()
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func variableTypeMismatch(name string, declared ast.TypeExpression, value object.Object) *object.Error {
+	return newError("type mismatch: variable %s declared %s, got %s", name, declared, typeName(value))
+}
+
+// typeName is the name under which the type of value is written in a
+// declaration.
+func typeName(value object.Object) string {
+	if value == nil {
+		return "null"
+	}
+
+	switch value.Type() {
+	case object.INTEGER_OBJ:
+		return "int"
+	case object.BOOLEAN_OBJ:
+		return "bool"
+	case object.BUILTIN_OBJ:
+		return "function"
+	default:
+		return strings.ToLower(string(value.Type()))
+	}
+}
+
 func extendFunctionEnv(
 	fn *object.Function,
 	arguments []object.Object,
@@ -320,7 +1239,11 @@ func extendFunctionEnv(
 	env := object.NewEnclosedEnvironment(fn.Env)
 
 	for parameterIndex, parameter := range fn.Parameters {
-		env.Set(parameter.Value, arguments[parameterIndex])
+		var declared ast.TypeExpression
+		if fn.ParameterTypes != nil {
+			declared = fn.ParameterTypes[parameterIndex]
+		}
+		env.Declare(parameter.Value, arguments[parameterIndex], declared)
 	}
 
 	return env
@@ -338,6 +1261,8 @@ func evaluateIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evaluateArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evaluateStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evaluateHashIndexExpression(left, index)
 	default:
@@ -347,7 +1272,11 @@ func evaluateIndexExpression(left, index object.Object) object.Object {
 
 func evaluateArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		return NULL // a BigInteger is never a valid index
+	}
+	idx := integer.Value
 	max := int64(len(arrayObject.Elements) - 1)
 
 	if idx < 0 || idx > max {
@@ -357,6 +1286,24 @@ func evaluateArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
+// evaluateStringIndexExpression indexes a string by code point, returning
+// the character as a one-rune string.
+func evaluateStringIndexExpression(str, index object.Object) object.Object {
+	characters := []rune(str.(*object.String).Value)
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		return NULL
+	}
+	idx := integer.Value
+	max := int64(len(characters) - 1)
+
+	if idx < 0 || idx > max {
+		return NULL
+	}
+
+	return &object.String{Value: string(characters[idx])}
+}
+
 func evaluateHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 
@@ -372,3 +1319,63 @@ func evaluateHashIndexExpression(hash, index object.Object) object.Object {
 
 	return pair.Value
 }
+
+func evaluateHashLiteral(
+	node *ast.HashLiteral,
+	env *object.Environment,
+) object.Object {
+	pairs := make(map[object.HashKey]object.HashPair)
+
+	// evaluate the pairs in source order, not in map order
+	keyNodes := []ast.Expression{}
+	for keyNode := range node.Pairs {
+		keyNodes = append(keyNodes, keyNode)
+	}
+	sort.Slice(keyNodes, func(i, j int) bool {
+		return keyNodes[i].Pos().Offset < keyNodes[j].Pos().Offset
+	})
+
+	for _, keyNode := range keyNodes {
+		valueNode := node.Pairs[keyNode]
+		key := Evaluate(keyNode, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("Unusable as hash key: %s", key.Type())
+		}
+
+		value := Evaluate(valueNode, env)
+		if isError(value) {
+			return value
+		}
+
+		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+
+	return &object.Hash{Pairs: pairs}
+}
+
+// evaluateInterpolatedString renders every embedded expression with Inspect
+// and joins it with the surrounding text.
+func evaluateInterpolatedString(
+	node *ast.InterpolatedString,
+	env *object.Environment,
+) object.Object {
+	var output bytes.Buffer
+
+	for i, expression := range node.Expressions {
+		value := Evaluate(expression, env)
+		if isError(value) {
+			return value
+		}
+
+		output.WriteString(node.Strings[i])
+		output.WriteString(value.Inspect())
+	}
+	output.WriteString(node.Strings[len(node.Strings)-1])
+
+	return &object.String{Value: output.String()}
+}
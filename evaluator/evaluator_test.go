@@ -1,9 +1,13 @@
 package evaluator
 
 import (
+	"Go-Tutorials/Core-lang/ast"
 	"Go-Tutorials/Core-lang/lexer"
 	"Go-Tutorials/Core-lang/object"
 	"Go-Tutorials/Core-lang/parser"
+	"math"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -27,6 +31,34 @@ func TestEvaluateIntegerExpression(t *testing.T) {
 		{"3 * 3 * 3 + 42", 69},
 		{"3 * (3 * 3) + 42", 69},
 		{"(10 + 3 * 30 + 20 / 2) * 2 + -120", 100},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"7 % -3", 1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"5 ** 0", 1},
+		{"1 ** (2 ** 40)", 1},
+		{"(-1) ** (2 ** 40 + 1)", -1},
+		{"0 ** (2 ** 70)", 0},
+		{"2 * 3 ** 2 % 5", 3},
+		{"12 & 10", 8},
+		{"12 | 10", 14},
+		{"12 ^ 10", 6},
+		{"~5", -6},
+		{"~-1", 0},
+		{"1 << 10", 1024},
+		{"1024 >> 3", 128},
+		{"-16 >> 2", -4},
+		{"-1 >> 100", -1},
+		{"5 >> 64", 0},
+		{"1 | 2 ^ 3 & 4", 3},
+		{"1 << 2 + 1", 8},
+		{"-1 & 255", 255},
+		{"(1 << 70) >> 68", 4},
+		{"(2 ** 64 + 7) & 5", 5},
+		{"~(2 ** 64) + 2 ** 64", -1},
 	}
 
 	for _, tt := range tests {
@@ -66,6 +98,25 @@ func TestEvaluateBooleanExpression(t *testing.T) {
 	}{
 		{"true", true},
 		{"false", false},
+		{"true == true", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"(1 < 2) == true", true},
+		{`"a" < "b"`, true},
+		{`"b" > "abc"`, true},
+		{`"abc" == "abc"`, true},
+		{`"abc" != "abd"`, true},
+		{`"é" > "z"`, true},
+		{`1 == "1"`, false},
+		{`1 != "1"`, true},
+		{"true == 1", false},
+		{"[1] == [1]", false},
+		{"{} == {}", false},
+		{"len == len", true},
+		{"function(a) { a == a }([1, 2])", true},
+		{"function(h) { h == h }({})", true},
+		{"if (false) { 1 } == if (false) { 2 }", true},
+		{"if (false) { 1 } == 0", false},
 	}
 
 	for _, tt := range tests {
@@ -134,9 +185,888 @@ func TestVarStatements(t *testing.T) {
 		{"var int a = 3; a;", 3},
 		{"var int a = 3 * 3; a;", 9},
 		{"var int a = 3; var int b = a; var int c = a + b + 3; c;", 9},
+		{"var a = 4; a;", 4},
+		{"var a = 2; var int b = a * 3; var c = b + 1; c;", 7},
 	}
 
 	for _, tt := range tests {
 		testIntegerObject(t, testEvaluate(tt.input), tt.expected)
 	}
 }
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"function(a, b) { a + b; }(1, 2)", 3},
+		{"function(x) { return x * 2; }(5)", 10},
+		{"function(a, b) { a - b }(10, function(x) { x }(4))", 6},
+		{"function() { 5 }()", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEvaluate(tt.input), tt.expected)
+	}
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1 + 1]", 3},
+		{"var int i = 1; [1, 2 * 3, 3][i]", 6},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"a": 1}["a"]`, 1},
+		{`{"a": 1, "b": 2 + 3}["b"]`, 5},
+		{`{"a": 1}["b"]`, nil},
+		{`{1: 10, true: 20}[1]`, 10},
+		{`{1: 10, true: 20}[true]`, 20},
+		{`{}["a"]`, nil},
+		{"{2 ** 64: 1}[2 ** 64]", 1},
+		{"{2 ** 64: 1}[2 ** 65]", nil},
+		{"{554774489934347788: 1}[2 ** 64]", nil},
+		{"len({554774489934347788: 1, 2 ** 64: 2})", 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashLiteralErrors(t *testing.T) {
+	evaluated := testEvaluate(`{[1]: 2}`)
+
+	errorObject, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. Got %T(%+v)", evaluated, evaluated)
+	}
+
+	if errorObject.Message != "Unusable as hash key: ARRAY" {
+		t.Errorf("wrong error message. Got %q", errorObject.Message)
+	}
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	if obj != NULL {
+		t.Errorf("object is not NULL. Got %T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("héllo")`, 5},
+		{`len("日本語")`, 3},
+		{`len([1, 2, 3])`, 3},
+		{`len({"a": 1})`, 1},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errorObject, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. Got %T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errorObject.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errorObject.Message)
+			}
+		}
+	}
+}
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"héllo"[0]`, "h"},
+		{`"héllo"[1]`, "é"},
+		{`"日本語"[2]`, "語"},
+		{`"abc"[3]`, nil},
+		{`"abc"[-1]`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		expected, ok := tt.expected.(string)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. Got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"plain"`, "plain"},
+		{`var string name = "Ada"; var int age = 36; "hello ${name}, you are ${age + 1}"`, "hello Ada, you are 37"},
+		{`"${1}${2}"`, "12"},
+		{`"list: ${[1, 2]} flag: ${true}"`, "list: [1, 2] flag: true"},
+		{`"nested ${"inner ${1 + 1}"}"`, "nested inner 2"},
+		{`"price: \${x}"`, "price: ${x}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. Got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+}
+
+func TestEvaluateFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.5", 3.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2},
+		{"7 / 2.0", 3.5},
+		{"10.0 - 2 * 3", 4},
+		{"1e3 / 4", 250},
+		{"var float pi = 3.14; pi * 2", 6.28},
+		{"7.5 % 2", 1.5},
+		{"2 ** 0.5 * 2 ** 0.5", 2.0000000000000004},
+		{"2.0 ** -1", 0.5},
+	}
+
+	for _, tt := range tests {
+		testFloatObject(t, testEvaluate(tt.input), tt.expected)
+	}
+}
+
+func TestNumericComparisons(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1 == 1.0", true},
+		{"0.1 + 0.2 == 0.3", false},
+		{"2.0 != 2", false},
+		{"1 <= 1", true},
+		{"2 <= 1", false},
+		{"1 >= 1", true},
+		{"1 >= 2", false},
+		{"1.5 <= 1.5", true},
+		{"2 >= 1.5", true},
+		{"9223372036854775807 + 1 >= 9223372036854775807", true},
+		{"9223372036854775807 + 1 <= 9223372036854775807", false},
+		{`"a" <= "a"`, true},
+		{`"b" >= "a"`, true},
+		{`"a" >= "b"`, false},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEvaluate(tt.input), tt.expected)
+	}
+}
+
+func TestConversionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"int(3.9)", int64(3)},
+		{"int(-3.9)", int64(-3)},
+		{`int("42")`, int64(42)},
+		{"int(7)", int64(7)},
+		{"float(2)", 2.0},
+		{`float("2.5")`, 2.5},
+		{"string(1.5)", "1.5"},
+		{"string(2.0)", "2.0"},
+		{"string(10)", "10"},
+		{`int("abc")`, `cannot convert "abc" to int`},
+		{`float(true)`, "argument to `float` not supported, got BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			testIntegerObject(t, evaluated, expected)
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			switch result := evaluated.(type) {
+			case *object.String:
+				if result.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, result.Value)
+				}
+			case *object.Error:
+				if result.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, result.Message)
+				}
+			default:
+				t.Errorf("unexpected object. Got %T (%+v)", evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("Object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if math.Abs(result.Value-expected) > 1e-9 {
+		t.Errorf("Object has wrong value. Got %g, want %g",
+			result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func TestIntegerPromotion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		isBig    bool
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808", true},
+		{"9223372036854775808", "9223372036854775808", true},
+		{"-9223372036854775808", "-9223372036854775808", false},
+		{"0x1_0000_0000_0000_0000 - 1", "18446744073709551615", true},
+		{"9223372036854775807 + 1 - 1", "9223372036854775807", false},
+		{"-9223372036854775807 - 2", "-9223372036854775809", true},
+		{"-9223372036854775807 - 1", "-9223372036854775808", false},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808", true},
+		{"4294967296 * 4294967296", "18446744073709551616", true},
+		{"4294967296 * 4294967296 / 4294967296", "4294967296", false},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808", true},
+		{"(-9223372036854775807 - 1) * -1", "9223372036854775808", true},
+		{"-1 * (-9223372036854775807 - 1)", "9223372036854775808", true},
+		{"2 * 3 * 4 * 5 * 6 * 7 * 8 * 9 * 10 * 11 * 12 * 13 * 14 * 15 * 16 * 17 * 18 * 19 * 20 * 21 * 22 * 23 * 24 * 25",
+			"15511210043330985984000000", true},
+		{`int("100000000000000000000")`, "100000000000000000000", true},
+		{"int(1e20)", "100000000000000000000", true},
+		{"2 ** 64", "18446744073709551616", true},
+		{"2 ** 64 / 2 ** 32", "4294967296", false},
+		{"1 << 64", "18446744073709551616", true},
+		{"9223372036854775807 << 1", "18446744073709551614", true},
+		{"(2 ** 64) | 1", "18446744073709551617", true},
+		{"~(2 ** 64)", "-18446744073709551617", true},
+		{"(2 ** 64 + 5) % 2 ** 64", "5", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		if evaluated == nil || evaluated.Type() != object.INTEGER_OBJ {
+			t.Errorf("object is not INTEGER. Got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong value for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+
+		if _, isBig := evaluated.(*object.BigInteger); isBig != tt.isBig {
+			t.Errorf("wrong representation for %q. expected big=%t, got %T", tt.input, tt.isBig, evaluated)
+		}
+	}
+}
+
+func TestBigIntegerComparisonsAndHashing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775807 + 1 > 9223372036854775807", true},
+		{"9223372036854775807 < 9223372036854775807 + 1", true},
+		{"9223372036854775807 + 1 == 9223372036854775806 + 2", true},
+		{"9223372036854775807 + 1 != 9223372036854775807 + 2", true},
+		{"9223372036854775807 + 1 == 1", false},
+		{"(9223372036854775807 + 1) * 1.0 == 9223372036854775808.0", true},
+		{`{9223372036854775807 + 1: 1}[9223372036854775806 + 2]`, 1},
+		{`{9223372036854775807 + 1: 1}[9223372036854775807]`, nil},
+		{`[1, 2][9223372036854775807 + 1]`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"1 / 0", "division by zero"},
+		{"10 / (5 - 5)", "division by zero"},
+		{"(9223372036854775807 + 1) / 0", "division by zero"},
+		{"1.5 / 0", "division by zero"},
+		{"1 / 0.0", "division by zero"},
+		{"1 / 0; 5", "division by zero"},
+		{"function(a, b) { a + b }(1)", "wrong number of arguments: want=2, got=1"},
+		{"function(a) { a }(1, 2)", "wrong number of arguments: want=1, got=2"},
+		{"function() { 1 / 0; 5 }()", "division by zero"},
+		{`1 + "1"`, "type mismatch: INTEGER + STRING"},
+		{`"1" * 2.5`, "type mismatch: STRING * FLOAT"},
+		{"true + 1", "type mismatch: BOOLEAN + INTEGER"},
+		{"true + false", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"true < false", "unknown operator: BOOLEAN < BOOLEAN"},
+		{`"a" - "b"`, "unknown operator: STRING - STRING"},
+		{"[1] + [2]", "unknown operator: ARRAY + ARRAY"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{`-"a"`, "unknown operator: -STRING"},
+		{"1 + true; 5", "type mismatch: INTEGER + BOOLEAN"},
+		{"if (1 > true) { 10 }", "type mismatch: INTEGER > BOOLEAN"},
+		{"true && 1 / 0", "division by zero"},
+		{"1 % 0", "modulo by zero"},
+		{"(9223372036854775807 + 1) % 0", "modulo by zero"},
+		{"1.5 % 0", "modulo by zero"},
+		{"2 ** -1", "negative exponent: -1"},
+		{`"a" % "b"`, "unknown operator: STRING % STRING"},
+		{"true <= false", "unknown operator: BOOLEAN <= BOOLEAN"},
+		{"1 << -1", "negative shift count: -1"},
+		{"1 >> -1", "negative shift count: -1"},
+		{"(2 ** 64) >> -2", "negative shift count: -2"},
+		{"1 << 2 ** 40", "shift count too large: 1099511627776"},
+		{"2 ** (2 ** 40)", "exponent too large: 1099511627776"},
+		{"(-3) ** 20000000", "exponent too large: 20000000"},
+		{"1 & 1.5", "type mismatch: INTEGER & FLOAT"},
+		{"1.5 | 2.5", "unknown operator: FLOAT | FLOAT"},
+		{"~1.5", "unknown operator: ~FLOAT"},
+		{"~true", "unknown operator: ~BOOLEAN"},
+		{"x = 1", "assignment to undeclared variable: x"},
+		{"x++", "assignment to undeclared variable: x"},
+		{"len = 1", "assignment to undeclared variable: len"},
+		{`var string s = "a"; s -= "b"`, "unknown operator: STRING - STRING"},
+		{"var b = true; b++", "type mismatch: BOOLEAN + INTEGER"},
+		{"var a = [1]; a[1] = 2", "index out of range: 1 with length 1"},
+		{"var a = [1]; a[-1] = 2", "index out of range: -1 with length 1"},
+		{`var a = [1]; a["x"] = 2`, "array index must be INTEGER, got STRING"},
+		{"var h = {}; h[[1]] = 2", "Unusable as hash key: ARRAY"},
+		{`var string s = "abc"; s[0] = "x"`, "Index assignment not supported: STRING"},
+		{"var int i = 1; i /= 0", "division by zero"},
+		{"while (undefined) { }", "identifier not found: undefined"},
+		{"var int i = 0; while (true) { i++; if (i == 3) { i / 0 } }", "division by zero"},
+		{"for (var int i = 0; i < 3; i = i + true) { }", "type mismatch: INTEGER + BOOLEAN"},
+		{"for (x = 1; true;) { }", "assignment to undeclared variable: x"},
+		{"for (x in 5) { }", "cannot iterate over INTEGER"},
+		{"for (x in undefined) { }", "identifier not found: undefined"},
+		{"for (x in [1, 0]) { 1 / x }", "division by zero"},
+		{"function add(a, b) { a + b } add(1)", "wrong number of arguments to `add`: want=2, got=1"},
+		{"function f() { g() } f()", "identifier not found: g"},
+		{"false || undefined", "identifier not found: undefined"},
+		{"function f(n) { f(n + 1) } f(0)", "maximum call depth of 10000 exceeded"},
+		{"var g = function(n) { if (n > 0) { g(n - 1) } else { 1 / 0 } }; g(50)", "division by zero"},
+		{"{1 / 0: 1, [1]: 2, \"a\": undefined}", "division by zero"},
+		{"{[1]: 1 / 0, \"a\": undefined}", "Unusable as hash key: ARRAY"},
+		{"var a = 1; var string s = a;", "type mismatch: variable s declared string, got int"},
+		{"var int x = 1.5 * 2;", "type mismatch: variable x declared int, got float"},
+		{"var int x = if (false) { 1 };", "type mismatch: variable x declared int, got null"},
+		{"var [int] xs = [1, true];", "type mismatch: variable xs declared [int], got array"},
+		{"var int x = 1; x = \"a\"", "type mismatch: variable x declared int, got string"},
+		{"var int x = 1; x += 0.5", "type mismatch: variable x declared int, got float"},
+		{"var int x = 1; var y = 2.5; x = y; x", "type mismatch: variable x declared int, got float"},
+		{"var int? x = 1; x = null; x = true", "type mismatch: variable x declared int?, got bool"},
+		{"var int x = 1; function() { x = \"a\" }(); x", "type mismatch: variable x declared int, got string"},
+		{"function(int n) { n = 1.5 }(1)", "type mismatch: variable n declared int, got float"},
+		{"var int x = 1; if (true) { var string x = 2; }", "type mismatch: variable x declared string, got int"},
+		{"for (var int i = 0; i < 3; i += 0.5) { }", "type mismatch: variable i declared int, got float"},
+		{`var [int] xs = [1]; function(v) { xs[0] = v }("s"); xs`, "type mismatch: element of xs declared int, got string"},
+		{"var [int] xs = [1]; xs[0] += 0.5", "type mismatch: element of xs declared int, got float"},
+		{"var [int]? xs = [1]; xs[0] = true", "type mismatch: element of xs declared int, got bool"},
+		{"var [[int]] m = [[1]]; m[0][0] = null", "type mismatch: element of m declared int, got null"},
+		{"var [[int]] m = [[1]]; m[0] = 2", "type mismatch: element of m declared [int], got int"},
+		{`var {string: int} h = {}; h["a"] = "b"`, "type mismatch: element of h declared int, got string"},
+		{`var {string: int} h = {}; h[1] = 2`, "type mismatch: key of h declared string, got int"},
+		{`var [int] | [string] xs = [1, 2]; xs[0] = "s"`, "type mismatch: variable xs declared [int] | [string], got array"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		errorObject, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. Got %T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errorObject.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errorObject.Message)
+		}
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{`1 && "a"`, true},
+		{"if (false) { 1 } || 0", true},
+		// the right operand is never evaluated, so its error never surfaces
+		{"false && 1 / 0", false},
+		{"true || undefined", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEvaluate(tt.input), tt.expected)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`"" + ""`, ""},
+		{`"caf" + "é"`, "café"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. Got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. Got %q, want %q", str.Value, tt.expected)
+		}
+	}
+}
+
+func TestAssignments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var int a = 1; a = 5; a", 5},
+		{"var int a = 1; a = 5", 5},
+		{"var int a = 1; var int b = 2; a = b = 7; a + b", 14},
+		{"var int a = 10; a += 5; a -= 3; a *= 2; a /= 4; a", 6},
+		{"var float f = 1.5; f *= 2; f", 3.0},
+		{`var string s = "ab"; s += "c"; s`, "abc"},
+		{"var int i = 1; i++", 1},
+		{"var int i = 1; i++; i", 2},
+		{"var int i = 1; ++i", 2},
+		{"var int i = 1; i--; --i; i", -1},
+		{"var int a = 1; function() { a = 2 }(); a", 2},
+		{"var int a = 1; function() { var int a = 5; a = 2 }(); a", 1},
+		{"var int count = 0; function() { count += 1 }(); function() { count++ }(); count", 2},
+		{"var int a = 9223372036854775807; a++; a", "9223372036854775808"},
+		{"var a = [1, 2, 3]; a[0] = 10; a[0] + a[2]", 13},
+		{"var a = [1, 2, 3]; a[1] *= 5; a[1]", 10},
+		{"var a = [1, 2, 3]; a[2]++; a[2]", 4},
+		{"var a = [1, 2, 3]; a[2]++", 3},
+		{`var h = {"k": 1}; h["k"] = 2; h["k"]`, 2},
+		{`var h = {}; h["new"] = 3; h["new"]`, 3},
+		{`var h = {"k": 1}; h["k"] += 4; h["k"]`, 5},
+		{"var a = [[1], [2]]; a[1][0] = 9; a[1][0]", 9},
+		{"var a = [1]; var b = a; b[0] = 2; a[0]", 2},
+		{`var int n = 0; var h = {"a": n += 1, "b": n *= 10, "c": n -= 3}; n`, 7},
+		{"var x = 1; x = \"a\"; x", "a"},
+		{"var int? x = 1; x = null; x = 2; x", 2},
+		{"var int | string x = 1; x = \"b\"; x", "b"},
+		{"var float f = 1.5; f = 2.5; f", 2.5},
+		{"var [int] xs = [1]; xs = [2, 3]; xs[1]", 3},
+		{"var int x = 1; function() { var x = \"a\"; x = 2.5; }(); x", 1},
+		{"var int x = 1; var x = \"a\"; x = true; x", "true"},
+		{"function(n) { n = \"a\"; n }(1)", "a"},
+		{"var [int] xs = [1]; xs[0] = 5; xs[0] += 2; xs[0]", 7},
+		{"var [int?] xs = [1]; xs[0] = null; xs[0]", "null"},
+		{`var {string: [int]} h = {}; h["a"] = [1]; h["a"][0] = 3; h["a"][0]`, 3},
+		{`var [int] | [string] xs = [1]; xs[0] = 2; xs[0]`, 2},
+		{`var xs = [1]; var [string] ys = ["a"]; xs[0] = "b"; xs[0]`, "b"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			if evaluated == nil || evaluated.Inspect() != expected {
+				t.Errorf("wrong value for %q. expected=%s, got=%+v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var int i = 0; while (i < 5) { i++; } i", 5},
+		{"var int i = 0; while (false) { i++; } i", 0},
+		{"var int sum = 0; for (var int i = 1; i <= 100; i++) { sum += i; } sum", 5050},
+		{"var int i = 0; for (; i < 3;) { i++; } i", 3},
+		{"var int i = 0; for (;;) { if (i == 4) { break; } i++; } i", 4},
+		{"var int i = 0; while (true) { i++; if (i > 9) { break; } } i", 10},
+		{"var int odd = 0; for (var int i = 0; i < 10; i++) { if (i % 2 == 0) { continue; } odd += i; } odd", 25},
+		{"var int n = 0; var int i = 0; while (i < 10) { i++; if (i > 3) { continue; } n++; } n", 3},
+		{"var int n = 0; for (var int i = 0; i < 3; i++) { for (var int j = 0; j < 3; j++) { if (j == 1) { break; } n++; } } n", 3},
+		{"function() { var int i = 0; while (true) { i++; if (i == 7) { return i * 2; } } }()", 14},
+		{"function() { for (var int i = 0; i < 10; i++) { return i; } }()", 0},
+		{"var int i = 100; for (var int i = 0; i < 3; i++) { } i", 100},
+		{"var int i = 0; for (i = 0; i < 3; i++) { } i", 3},
+		{"var int i = 0; while (i < 100000) { i++; } i", 100000},
+		{"while (false) { }", nil},
+		{"for (var int i = 0; i < 3; i++) { i }", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		if expected, ok := tt.expected.(int); ok {
+			testIntegerObject(t, evaluated, int64(expected))
+		} else if evaluated != nil {
+			t.Errorf("expected no value for %q, got %T (%+v)", tt.input, evaluated, evaluated)
+		}
+	}
+}
+
+func TestForInLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var int sum = 0; for (x in [1, 2, 3]) { sum += x; } sum", 6},
+		{"var int sum = 0; for (i, x in [10, 20, 30]) { sum += i * x; } sum", 80},
+		{`var string out = ""; for (ch in "héllo") { out = ch + out; } out`, "olléh"},
+		{`var string out = ""; for (i, ch in "ab") { out += "${i}${ch}"; } out`, "0a1b"},
+		{`var string out = ""; for (k in {"b": 2, "a": 1, "c": 3}) { out += k; } out`, "abc"},
+		{`var string out = ""; for (k, v in {"b": 2, "a": 1}) { out += "${k}=${v};"; } out`, "a=1;b=2;"},
+		{`var string out = ""; for (k in {true: 1, 2: 1, "x": 1, false: 1, -5: 1}) { out += "${k} "; } out`,
+			"false true -5 2 x "},
+		{"var int sum = 0; for (i in range(5)) { sum += i; } sum", 10},
+		{"var int sum = 0; for (i in range(2, 5)) { sum += i; } sum", 9},
+		{`var string out = ""; for (i in range(10, 0, -3)) { out += "${i},"; } out`, "10,7,4,1,"},
+		{"var int n = 0; for (i in range(5, 5)) { n++; } n", 0},
+		{"var int n = 0; for (i in range(9223372036854775805, 9223372036854775807, 2)) { n++; } n", 1},
+		{"var int n = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } n += x; } n", 3},
+		{"var int n = 0; for (x in [1, 2, 3, 4]) { if (x % 2 == 0) { continue; } n += x; } n", 4},
+		{"function() { for (x in [5, 6]) { return x; } }()", 5},
+		{"var int x = 42; for (x in [1, 2]) { } x", 42},
+		{"var fs = [0, 0]; for (i, x in [7, 8]) { fs[i] = function() { x }; } fs[0]() + fs[1]()", 15},
+		{"for (x in []) { }", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("wrong value for %q. expected=%q, got=%+v", tt.input, expected, evaluated)
+			}
+		default:
+			if evaluated != nil {
+				t.Errorf("expected no value for %q, got %T (%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestRangeBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"range(3)", "range(0, 3)"},
+		{"range(1, 4)", "range(1, 4)"},
+		{"range(4, 1, -1)", "range(4, 1, -1)"},
+		{"range()", "ERROR: wrong number of arguments. got=0, want=1..3"},
+		{"range(1.5)", "ERROR: argument to `range` not supported, got FLOAT"},
+		{"range(0, 2 ** 64)", "ERROR: range bound 18446744073709551616 does not fit in int64"},
+		{"range(0, 10, 0)", "ERROR: range step must not be zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestFunctionDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"function add(a, b) { a + b } add(2, 3)", 5},
+		{"var int x = twice(4); function twice(n) { n * 2 } x", 8},
+		{`function isEven(n) { if (n == 0) { return true; } isOdd(n - 1) }
+		  function isOdd(n) { if (n == 0) { return false; } isEven(n - 1) }
+		  if (isEven(10) && isOdd(7)) { 1 } else { 0 }`, 1},
+		{"function fact(n) { if (n < 2) { return 1; } n * fact(n - 1) } fact(10)", 3628800},
+		{`function outer() { return inner() * 2; function inner() { 21 } } outer()`, 42},
+		{`function counter() { var int n = 0; function next() { n++; n } next }
+		  var c = counter(); c(); c(); c()`, 3},
+		{"var int sum = 0; for (i in range(3)) { function add() { sum += i } add(); } sum", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEvaluate(tt.input), tt.expected)
+	}
+}
+
+func TestTypedFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"function add(int a, int b): int { a + b } add(1, 2)", 3},
+		{`function(string s, n): string { s + string(n) }("n=", 4)`, "n=4"},
+		{"function half(float x): float { return x / 2; } half(3.0)", 1.5},
+		{"function(int a) { a }(2 ** 64) == 2 ** 64", true},
+		{"function add(int a, int b): int { a + b } add(1, \"2\")",
+			"type mismatch: parameter b to `add` expects int, got string"},
+		{"function(float x) { x }(1)", "type mismatch: parameter x expects float, got int"},
+		{"function f(): int { \"no\" } f()", "type mismatch: `f` returned string, declared int"},
+		{"function(): string { return 1; }()", "type mismatch: function returned int, declared string"},
+		{"function(): int { }()", "type mismatch: function returned null, declared int"},
+		{"function(): int { 1 / 0 }()", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			if evaluated == nil {
+				t.Errorf("no result for %q", tt.input)
+			} else if errorObject, ok := evaluated.(*object.Error); ok {
+				if errorObject.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errorObject.Message)
+				}
+			} else if evaluated.Inspect() != expected {
+				t.Errorf("wrong value for %q. expected=%q, got=%q", tt.input, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestCompoundParameterTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"function(bool b) { b }(true)", true},
+		{"function(int? n) { n == null }(null)", true},
+		{"function(int? n) { n }(3)", 3},
+		{`function(int | string v) { v }("s")`, "s"},
+		{"function([int] xs) { len(xs) }([1, 2, 3])", 3},
+		{"function([int] xs) { len(xs) }([])", 0},
+		{`function({string: int} h) { h["a"] }({"a": 1})`, 1},
+		{"function(function(int): int f) { f(2) }(function(int x): int { x * 3 })", 6},
+		{"function(function(int): int f) { f(2) }(function(x) { x + 1 })", 3},
+		{"function(function(string): int f) { len(\"ab\") }(len)", 2},
+		{"function(): [int]? { null }() == null", true},
+		{"function f() {} var int? x = f(); x == null", true},
+		{"function f(): int? { var y = 1; } f() == null", true},
+		{"function g(v) { v } g(function() {}()) == null", true},
+		{"null", nil},
+		{"function(bool b) { b }(1)", "type mismatch: parameter b expects bool, got int"},
+		{"function(int? n) { n }(1.5)", "type mismatch: parameter n expects int?, got float"},
+		{`function([int] xs) { xs }([1, "2"])`, "type mismatch: parameter xs expects [int], got array"},
+		{`function({string: int} h) { h }({1: 1})`, "type mismatch: parameter h expects {string: int}, got hash"},
+		{"function(function(int): int f) { f }(function(a, b) { a })",
+			"type mismatch: parameter f expects function(int): int, got function"},
+		{"function(function(int): int f) { f }(function(string s) { 1 })",
+			"type mismatch: parameter f expects function(int): int, got function"},
+		{"function(): int | string { true }()", "type mismatch: function returned bool, declared int | string"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if errorObject, ok := evaluated.(*object.Error); ok {
+				if errorObject.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errorObject.Message)
+				}
+			} else if evaluated == nil || evaluated.Inspect() != expected {
+				t.Errorf("wrong value for %q. expected=%q, got=%+v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestFunctionInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"function add(a, b) { a + b } add", "function add(a, b) {\n(a + b)\n}"},
+		{"function(x) { x }", "function(x) {\nx\n}"},
+		{"function(int x, y): string { y }", "function(int x, y): string {\ny\n}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvaluate(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect for %q. expected=%q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+}
+
+func TestCallDepthLimit(t *testing.T) {
+	evaluated := testEvaluate("function f(n) { f(n + 1) } f(0)")
+	if _, ok := evaluated.(*object.Error); !ok {
+		t.Fatalf("no error object returned. Got %T (%+v)", evaluated, evaluated)
+	}
+
+	// the depth is restored after the error, so recursion just below the
+	// limit still works
+	evaluated = testEvaluate("function count(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } } count(9999)")
+	testIntegerObject(t, evaluated, 9999)
+
+	// concurrent evaluations each have their own depth
+	var wait sync.WaitGroup
+	results := make([]object.Object, 4)
+	for i := range results {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			results[i] = testEvaluate("function count(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } } count(9000)")
+		}(i)
+	}
+	wait.Wait()
+	for _, result := range results {
+		testIntegerObject(t, result, 9000)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	// an infix expression without a left operand can only come from a
+	// hand-built tree; evaluating it must not crash the caller
+	program := &ast.Program{
+		Statements: []ast.Statement{
+			&ast.ExpressionStatement{
+				Expression: &ast.InfixExpression{
+					Operator: "+",
+					Right:    &ast.IntegerLiteral{Value: 1},
+				},
+			},
+		},
+	}
+
+	evaluated := Evaluate(program, object.NewEnvironment())
+
+	errorObject, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. Got %T (%+v)", evaluated, evaluated)
+	}
+
+	if !strings.HasPrefix(errorObject.Message, "internal error: ") {
+		t.Errorf("wrong error message. Got %q", errorObject.Message)
+	}
+}
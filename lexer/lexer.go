@@ -1,35 +1,123 @@
 package lexer
 
-import "Go-Tutorials/Core-lang/token"
+import (
+	"Go-Tutorials/Core-lang/diagnostic"
+	"Go-Tutorials/Core-lang/token"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mode controls optional lexer behaviour.
+type Mode uint
+
+const (
+	// ScanComments makes NextToken return comments as token.COMMENT
+	// instead of skipping them.
+	ScanComments Mode = 1 << iota
+)
 
 type Lexer struct {
 	input    string
+	filename string
+	mode     Mode
+	errors   []*diagnostic.Diagnostic
 	position int  // current position in input (point to current char)
 	readPos  int  // current reading position in input (after current char)
-	char     byte // current char under examination
+	char     rune // current char under examination
+	line     int  // line of the current char
+	column   int  // column of the current char, counted in runes
+
+	// interpolations has an entry for every "${" whose closing "}" has not
+	// been reached yet, innermost last.
+	interpolations []interpolation
+}
+
+type interpolation struct {
+	start    token.Position // start of the string containing the "${"
+	braces   int            // unmatched "{" inside the embedded expression
+	reported bool           // a string inside the expression was unterminated
 }
 
 func New(input string) *Lexer {
-	lexInstance := &Lexer{input: input}
+	return NewFile("", input)
+}
+
+// NewFile creates a lexer whose token positions report the given filename.
+func NewFile(filename, input string) *Lexer {
+	lexInstance := &Lexer{input: input, filename: filename, line: 1}
 	lexInstance.readCharacter()
+	if lexInstance.char == '\uFEFF' {
+		// skip a leading byte order mark without counting it as a column
+		lexInstance.readCharacter()
+		lexInstance.column = 1
+	}
 	return lexInstance
 }
 
+// SetMode changes the lexer mode for the tokens that follow.
+func (lex *Lexer) SetMode(mode Mode) {
+	lex.mode = mode
+}
+
+// Errors returns the diagnostics reported so far, such as unterminated
+// comments.
+func (lex *Lexer) Errors() []*diagnostic.Diagnostic {
+	return lex.errors
+}
+
 func (lex *Lexer) readCharacter() {
+	if lex.char == '\n' {
+		lex.line += 1
+		lex.column = 0
+	}
+
+	width := 1
 	if lex.readPos >= len(lex.input) {
 		lex.char = 0
 	} else {
-		lex.char = lex.input[lex.readPos]
+		lex.char, width = utf8.DecodeRuneInString(lex.input[lex.readPos:])
 	}
 
 	lex.position = lex.readPos
-	lex.readPos += 1
+	lex.readPos += width
+	lex.column += 1
+
+	if lex.char == utf8.RuneError && width == 1 {
+		span := diagnostic.Span{Start: lex.currentPosition(), End: lex.currentPosition()}
+		span.End.Offset += 1
+		span.End.Column += 1
+		lex.errors = append(lex.errors, diagnostic.New(diagnostic.InvalidEncoding, span,
+			"invalid UTF-8 encoding (byte %#x)", lex.input[lex.position]))
+	}
+}
+
+func (lex *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Filename: lex.filename,
+		Offset:   lex.position,
+		Line:     lex.line,
+		Column:   lex.column,
+	}
 }
 
 func (lex *Lexer) NextToken() token.Token {
 	var currentToken token.Token
 
-	lex.ignoreWhitespace()
+	for {
+		lex.ignoreWhitespace()
+		if !lex.atComment() {
+			break
+		}
+
+		comment := lex.readComment()
+		if lex.mode&ScanComments != 0 {
+			return comment
+		}
+	}
+	pos := lex.currentPosition()
 
 	switch lex.char {
 	case '=':
@@ -50,11 +138,45 @@ func (lex *Lexer) NextToken() token.Token {
 	case ',':
 		currentToken = newToken(token.COMMA, lex.char)
 	case '+':
-		currentToken = newToken(token.PLUS, lex.char)
+		switch lex.peekAheadCharacter() {
+		case '+':
+			currentToken = lex.readTwoCharacterToken(token.INCREMENT)
+		case '=':
+			currentToken = lex.readTwoCharacterToken(token.PLUS_ASSIGN)
+		default:
+			currentToken = newToken(token.PLUS, lex.char)
+		}
 	case '{':
+		if len(lex.interpolations) > 0 {
+			lex.interpolations[len(lex.interpolations)-1].braces++
+		}
 		currentToken = newToken(token.LEFT_CURLY_BRACE, lex.char)
 	case '}':
-		currentToken = newToken(token.RIGHT_CURLY_BRACE, lex.char)
+		if top := len(lex.interpolations) - 1; top >= 0 && lex.interpolations[top].braces == 0 {
+			// end of an embedded expression, the string continues
+			open := lex.interpolations[top]
+			lex.interpolations = lex.interpolations[:top]
+
+			literal, interpolated := lex.readString(open.start, open.reported)
+			currentToken.Type = token.STRING_TAIL
+			if interpolated {
+				currentToken.Type = token.STRING_MIDDLE
+			}
+			currentToken.Literal = literal
+		} else {
+			if top >= 0 {
+				lex.interpolations[top].braces--
+			}
+			currentToken = newToken(token.RIGHT_CURLY_BRACE, lex.char)
+		}
+	case '[':
+		currentToken = newToken(token.LEFT_BRACKET, lex.char)
+	case ']':
+		currentToken = newToken(token.RIGHT_BRACKET, lex.char)
+	case ':':
+		currentToken = newToken(token.COLON, lex.char)
+	case '?':
+		currentToken = newToken(token.QUESTION, lex.char)
 	case '!':
 		if lex.peekAheadCharacter() == '=' {
 			char := lex.char
@@ -65,30 +187,92 @@ func (lex *Lexer) NextToken() token.Token {
 			currentToken = newToken(token.BANG, lex.char)
 		}
 	case '-':
-		currentToken = newToken(token.MINUS, lex.char)
+		switch lex.peekAheadCharacter() {
+		case '-':
+			currentToken = lex.readTwoCharacterToken(token.DECREMENT)
+		case '=':
+			currentToken = lex.readTwoCharacterToken(token.MINUS_ASSIGN)
+		default:
+			currentToken = newToken(token.MINUS, lex.char)
+		}
+	case '&':
+		if lex.peekAheadCharacter() == '&' {
+			currentToken = lex.readTwoCharacterToken(token.AND)
+		} else {
+			currentToken = newToken(token.BIT_AND, lex.char)
+		}
+	case '|':
+		if lex.peekAheadCharacter() == '|' {
+			currentToken = lex.readTwoCharacterToken(token.OR)
+		} else {
+			currentToken = newToken(token.BIT_OR, lex.char)
+		}
+	case '^':
+		currentToken = newToken(token.BIT_XOR, lex.char)
+	case '~':
+		currentToken = newToken(token.BIT_NOT, lex.char)
 	case '>':
-		currentToken = newToken(token.GREATER_THEN, lex.char)
+		if lex.peekAheadCharacter() == '=' {
+			currentToken = lex.readTwoCharacterToken(token.GREATER_EQUAL)
+		} else if lex.peekAheadCharacter() == '>' {
+			currentToken = lex.readTwoCharacterToken(token.SHIFT_RIGHT)
+		} else {
+			currentToken = newToken(token.GREATER_THEN, lex.char)
+		}
 	case '<':
-		currentToken = newToken(token.LESS_THEN, lex.char)
+		if lex.peekAheadCharacter() == '=' {
+			currentToken = lex.readTwoCharacterToken(token.LESS_EQUAL)
+		} else if lex.peekAheadCharacter() == '<' {
+			currentToken = lex.readTwoCharacterToken(token.SHIFT_LEFT)
+		} else {
+			currentToken = newToken(token.LESS_THEN, lex.char)
+		}
 	case '*':
-		currentToken = newToken(token.ASTERISK, lex.char)
+		if lex.peekAheadCharacter() == '*' {
+			currentToken = lex.readTwoCharacterToken(token.POWER)
+		} else if lex.peekAheadCharacter() == '=' {
+			currentToken = lex.readTwoCharacterToken(token.ASTERISK_ASSIGN)
+		} else {
+			currentToken = newToken(token.ASTERISK, lex.char)
+		}
+	case '%':
+		currentToken = newToken(token.PERCENT, lex.char)
 	case '/':
-		currentToken = newToken(token.SLASH, lex.char)
+		if lex.peekAheadCharacter() == '=' {
+			currentToken = lex.readTwoCharacterToken(token.SLASH_ASSIGN)
+		} else {
+			currentToken = newToken(token.SLASH, lex.char)
+		}
 	case '"':
+		literal, interpolated := lex.readString(pos, false)
+		currentToken.Type = token.STRING
+		if interpolated {
+			currentToken.Type = token.STRING_HEAD
+		}
+		currentToken.Literal = literal
+	case '`':
 		currentToken.Type = token.STRING
-		currentToken.Literal = lex.readString()
+		currentToken.Literal = lex.readRawString(pos)
 
 	case 0:
+		// a string left open inside the expression is the same mistake
+		if len(lex.interpolations) > 0 && !lex.interpolations[0].reported {
+			lex.unterminatedString(lex.interpolations[0].start)
+		}
+		lex.interpolations = nil
 		currentToken.Literal = ""
 		currentToken.Type = token.END
 	default:
 		if isAlphabetic(lex.char) {
 			currentToken.Literal = lex.searchIdentifier()
 			currentToken.Type = token.LookupIdentifier(currentToken.Literal)
+			currentToken.Pos = pos
+			currentToken.End = lex.currentPosition()
 			return currentToken
 		} else if isNumber(lex.char) {
-			currentToken.Type = token.INT
-			currentToken.Literal = lex.checkNumber()
+			currentToken.Type, currentToken.Literal = lex.checkNumber()
+			currentToken.Pos = pos
+			currentToken.End = lex.currentPosition()
 			return currentToken
 		} else {
 			currentToken = newToken(token.INVALID, lex.char)
@@ -96,10 +280,12 @@ func (lex *Lexer) NextToken() token.Token {
 	}
 
 	lex.readCharacter()
+	currentToken.Pos = pos
+	currentToken.End = lex.currentPosition()
 	return currentToken
 }
 
-func newToken(tokenType token.TokenType, char byte) token.Token {
+func newToken(tokenType token.TokenType, char rune) token.Token {
 	if tokenType == "=" {
 		return token.Token{Type: token.ASSIGN_OP, Literal: string(char)}
 	} else if tokenType == ";" {
@@ -109,17 +295,28 @@ func newToken(tokenType token.TokenType, char byte) token.Token {
 	}
 }
 
+// readTwoCharacterToken consumes the current and the next character as one
+// token of the given type.
+func (lex *Lexer) readTwoCharacterToken(tokenType token.TokenType) token.Token {
+	char := lex.char
+	lex.readCharacter()
+	return token.Token{Type: tokenType, Literal: string(char) + string(lex.char)}
+}
+
 func (lex *Lexer) searchIdentifier() string {
 	position := lex.position
-	for isAlphabetic(lex.char) || isNumber(lex.char) {
+	for isAlphabetic(lex.char) || unicode.IsDigit(lex.char) {
 		lex.readCharacter()
 	}
 
 	return lex.input[position:lex.position]
 }
 
-func isAlphabetic(char byte) bool {
-	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || char == '_'
+// isAlphabetic reports whether char may start an identifier: any Unicode
+// letter (categories Lu, Ll, Lt, Lm and Lo) or an underscore. After the first
+// character identifiers may also contain Unicode decimal digits (Nd).
+func isAlphabetic(char rune) bool {
+	return unicode.IsLetter(char) || char == '_'
 }
 
 func (lex *Lexer) ignoreWhitespace() {
@@ -128,35 +325,348 @@ func (lex *Lexer) ignoreWhitespace() {
 	}
 }
 
-func (lex *Lexer) checkNumber() string {
-	position := lex.position
-	for isNumber(lex.char) {
+// checkNumber reads an integer or a float literal. Integers may be written
+// in hexadecimal ("0xFF"), octal ("0o755") or binary ("0b1010"), and any
+// number may use underscores between digits ("1_000_000"). A float has a
+// fraction ("3.14"), an exponent ("1e9", "2.5E-3") or both; a dot must be
+// followed by a digit to be part of the number. Malformed literals are
+// reported but still returned as a single token.
+func (lex *Lexer) checkNumber() (token.TokenType, string) {
+	start := lex.currentPosition()
+	tokenType := token.TokenType(token.INT)
+
+	if lex.char == '0' && strings.ContainsRune("xXoObB", lex.peekAheadCharacter()) {
+		lex.readCharacter()
 		lex.readCharacter()
+		for isNumber(lex.char) || isASCIILetter(lex.char) || lex.char == '_' {
+			lex.readCharacter()
+		}
+
+		lex.validateNumber(start, tokenType)
+		return tokenType, lex.input[start.Offset:lex.position]
 	}
 
-	return lex.input[position:lex.position]
+	lex.readDecimalDigits()
+
+	if lex.char == '.' && isNumber(lex.peekAheadCharacter()) {
+		tokenType = token.FLOAT
+		lex.readCharacter()
+		lex.readDecimalDigits()
+	}
+
+	if lex.char == 'e' || lex.char == 'E' {
+		tokenType = token.FLOAT
+		lex.readCharacter()
+		if lex.char == '+' || lex.char == '-' {
+			lex.readCharacter()
+		}
+		lex.readDecimalDigits()
+	}
+
+	lex.validateNumber(start, tokenType)
+	return tokenType, lex.input[start.Offset:lex.position]
+}
+
+func (lex *Lexer) readDecimalDigits() {
+	for isNumber(lex.char) || lex.char == '_' {
+		lex.readCharacter()
+	}
 }
 
-func isNumber(char byte) bool {
+var numberBases = map[byte]struct {
+	base int
+	name string
+}{
+	'x': {16, "hexadecimal"},
+	'o': {8, "octal"},
+	'b': {2, "binary"},
+}
+
+// validateNumber reports the first problem with the number literal that
+// started at start and ends at the current char.
+func (lex *Lexer) validateNumber(start token.Position, tokenType token.TokenType) {
+	literal := lex.input[start.Offset:lex.position]
+
+	message := ""
+	switch {
+	case len(literal) > 1 && literal[0] == '0' && numberBases[literal[1]|0x20].base != 0:
+		base := numberBases[literal[1]|0x20]
+		message = checkDigits(literal, 2, base.base, base.name)
+	case tokenType == token.INT && len(literal) > 1 && literal[0] == '0':
+		// a leading zero marks a legacy octal literal, as in Go
+		message = checkDigits(literal, 1, 8, "octal")
+	default:
+		message = checkDecimalNumber(literal)
+	}
+
+	if message != "" {
+		span := diagnostic.Span{Start: start, End: lex.currentPosition()}
+		lex.errors = append(lex.errors, diagnostic.New(diagnostic.InvalidNumber, span, "%s", message))
+	}
+}
+
+// checkDigits validates the digits of an integer literal following a prefix
+// of the given length. The prefix counts as a digit for the rule that '_'
+// must separate successive digits.
+func checkDigits(literal string, prefix int, base int, name string) string {
+	digits := 0
+
+	for i := prefix; i < len(literal); i++ {
+		char := literal[i]
+		if char == '_' {
+			if i+1 == len(literal) || literal[i+1] == '_' {
+				return fmt.Sprintf("'_' must separate successive digits in %q", literal)
+			}
+			continue
+		}
+
+		if digitValue(char) >= base {
+			return fmt.Sprintf("invalid digit %q in %s literal %q", char, name, literal)
+		}
+		digits++
+	}
+
+	if digits == 0 && prefix > 1 {
+		return fmt.Sprintf("%s literal %q has no digits", name, literal)
+	}
+	return ""
+}
+
+// checkDecimalNumber validates a decimal integer or float literal.
+func checkDecimalNumber(literal string) string {
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '_' {
+			continue
+		}
+		if i+1 == len(literal) || !isNumber(rune(literal[i+1])) || !isNumber(rune(literal[i-1])) {
+			return fmt.Sprintf("'_' must separate successive digits in %q", literal)
+		}
+	}
+
+	if last := literal[len(literal)-1]; !isNumber(rune(last)) && last != '_' {
+		return fmt.Sprintf("exponent has no digits in %q", literal)
+	}
+	return ""
+}
+
+func digitValue(char byte) int {
+	switch {
+	case '0' <= char && char <= '9':
+		return int(char - '0')
+	case 'a' <= char|0x20 && char|0x20 <= 'z':
+		return int(char|0x20-'a') + 10
+	default:
+		return 36
+	}
+}
+
+func isASCIILetter(char rune) bool {
+	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z'
+}
+
+// isNumber only accepts ASCII digits; number literals are never written
+// with other scripts' digits.
+func isNumber(char rune) bool {
 	return '0' <= char && char <= '9'
 }
 
-func (lex *Lexer) peekAheadCharacter() byte {
+func (lex *Lexer) peekAheadCharacter() rune {
 	if lex.readPos >= len(lex.input) {
 		return 0
 	} else {
-		return lex.input[lex.readPos]
+		char, _ := utf8.DecodeRuneInString(lex.input[lex.readPos:])
+		return char
+	}
+}
+
+// readString reads a double quoted string, or the rest of one after an
+// embedded expression, starting at the opening quote or the "}" and returns
+// its decoded value. The string must end on the same line; escape sequences
+// are decoded as described in readEscape.
+//
+// When the string contains "${" reading stops there, the interpolation is
+// recorded so the matching "}" resumes the string, and interpolated is true.
+// If reported is set, the string was already found to be unterminated while
+// reading an embedded expression, and that is not reported again.
+func (lex *Lexer) readString(start token.Position, reported bool) (value string, interpolated bool) {
+	var output strings.Builder
+
+	for {
+		lex.readCharacter()
+
+		switch lex.char {
+		case '"':
+			return output.String(), false
+		case 0, '\n':
+			if !reported {
+				lex.unterminatedString(start)
+			}
+			return output.String(), false
+		case '$':
+			if lex.peekAheadCharacter() == '{' {
+				lex.readCharacter()
+				lex.interpolations = append(lex.interpolations, interpolation{start: start, reported: reported})
+				return output.String(), true
+			}
+			output.WriteRune(lex.char)
+		case '\\':
+			if next := lex.peekAheadCharacter(); next == 0 || next == '\n' {
+				lex.readCharacter()
+				if !reported {
+					lex.unterminatedString(start)
+				}
+				return output.String(), false
+			}
+			lex.readEscape(&output)
+		default:
+			output.WriteRune(lex.char)
+		}
 	}
 }
 
-func (lex *Lexer) readString() string {
+// readRawString reads a backtick quoted string, which may span several lines
+// and has no escape sequences.
+func (lex *Lexer) readRawString(start token.Position) string {
 	position := lex.position + 1
 	for {
 		lex.readCharacter()
-		if lex.char == '"' || lex.char == 0 {
+		if lex.char == '`' {
+			break
+		}
+		if lex.char == 0 {
+			lex.unterminatedString(start)
 			break
 		}
 	}
 
 	return lex.input[position:lex.position]
 }
+
+func (lex *Lexer) unterminatedString(start token.Position) {
+	for i := range lex.interpolations {
+		lex.interpolations[i].reported = true
+	}
+
+	span := diagnostic.Span{Start: start, End: lex.currentPosition()}
+	lex.errors = append(lex.errors, diagnostic.New(diagnostic.UnterminatedString, span,
+		"unterminated string literal").
+		WithHint("close the string with a matching quote, or use `...` for multi-line strings"))
+}
+
+var simpleEscapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'\\': '\\',
+	'"':  '"',
+	'\'': '\'',
+	'$':  '$',
+}
+
+// readEscape decodes the escape sequence whose backslash is the current
+// char. Besides the single character escapes \n \t \r \0 \\ \" \' and \$,
+// code points can be written as \xHH, \uHHHH or \UHHHHHHHH. Invalid escapes
+// are reported and left out of the value.
+func (lex *Lexer) readEscape(value *strings.Builder) {
+	start := lex.currentPosition()
+	lex.readCharacter()
+
+	if char, ok := simpleEscapes[lex.char]; ok {
+		value.WriteRune(char)
+		return
+	}
+
+	digits := 0
+	switch lex.char {
+	case 'x':
+		digits = 2
+	case 'u':
+		digits = 4
+	case 'U':
+		digits = 8
+	default:
+		lex.invalidEscape(start, "unknown escape sequence \\%c", lex.char)
+		return
+	}
+
+	kind := lex.char
+	for i := 0; i < digits; i++ {
+		if !isHexDigit(lex.peekAheadCharacter()) {
+			lex.invalidEscape(start, "escape sequence \\%c needs %d hexadecimal digits", kind, digits)
+			return
+		}
+		lex.readCharacter()
+	}
+
+	code, _ := strconv.ParseUint(lex.input[start.Offset+2:lex.position+1], 16, 32)
+	if !utf8.ValidRune(rune(code)) {
+		lex.invalidEscape(start, "escape sequence is not a valid Unicode code point")
+		return
+	}
+	value.WriteRune(rune(code))
+}
+
+func (lex *Lexer) invalidEscape(start token.Position, format string, a ...interface{}) {
+	end := lex.currentPosition()
+	end.Offset = lex.readPos
+	end.Column += 1
+
+	span := diagnostic.Span{Start: start, End: end}
+	lex.errors = append(lex.errors, diagnostic.New(diagnostic.InvalidEscape, span, format, a...))
+}
+
+func isHexDigit(char rune) bool {
+	return '0' <= char && char <= '9' || 'a' <= char && char <= 'f' || 'A' <= char && char <= 'F'
+}
+
+func (lex *Lexer) atComment() bool {
+	return lex.char == '/' && (lex.peekAheadCharacter() == '/' || lex.peekAheadCharacter() == '*')
+}
+
+// readComment consumes a "//" comment up to the end of the line or a "/* */"
+// comment, which may be nested, and returns it as a COMMENT token whose
+// literal is the full comment text.
+func (lex *Lexer) readComment() token.Token {
+	pos := lex.currentPosition()
+
+	if lex.peekAheadCharacter() == '/' {
+		for lex.char != '\n' && lex.char != 0 {
+			lex.readCharacter()
+		}
+	} else {
+		lex.readBlockComment(pos)
+	}
+
+	return token.Token{
+		Type:    token.COMMENT,
+		Literal: lex.input[pos.Offset:lex.position],
+		Pos:     pos,
+		End:     lex.currentPosition(),
+	}
+}
+
+func (lex *Lexer) readBlockComment(start token.Position) {
+	depth := 0
+
+	for {
+		switch {
+		case lex.char == 0:
+			span := diagnostic.Span{Start: start, End: lex.currentPosition()}
+			lex.errors = append(lex.errors, diagnostic.New(diagnostic.UnterminatedComment, span,
+				"unterminated block comment").WithHint("close the comment with '*/'"))
+			return
+		case lex.char == '/' && lex.peekAheadCharacter() == '*':
+			depth++
+			lex.readCharacter()
+		case lex.char == '*' && lex.peekAheadCharacter() == '/':
+			depth--
+			lex.readCharacter()
+			if depth == 0 {
+				lex.readCharacter()
+				return
+			}
+		}
+		lex.readCharacter()
+	}
+}
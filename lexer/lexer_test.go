@@ -9,6 +9,8 @@ func TestNextToken(t *testing.T) {
 	input := `
 	var string b = "abc";
 	var int a = 5;
+	add(a, [1, 2][0]);
+	{"key": a}
 	`
 
 	tests := []struct {
@@ -27,6 +29,25 @@ func TestNextToken(t *testing.T) {
 		{token.ASSIGN_OP, "="},
 		{token.INT, "5"},
 		{token.SEMICOLON, ";"},
+		{token.IDENT, "add"},
+		{token.LEFT_PARANTHESIS, "("},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.LEFT_BRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RIGHT_BRACKET, "]"},
+		{token.LEFT_BRACKET, "["},
+		{token.INT, "0"},
+		{token.RIGHT_BRACKET, "]"},
+		{token.RIGHT_PARANTHESIS, ")"},
+		{token.SEMICOLON, ";"},
+		{token.LEFT_CURLY_BRACE, "{"},
+		{token.STRING, "key"},
+		{token.COLON, ":"},
+		{token.IDENT, "a"},
+		{token.RIGHT_CURLY_BRACE, "}"},
 		{token.END, ""},
 	}
 
@@ -46,3 +67,503 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "var int a = 5;\n\tif (a > 3) {\n  \"done\"\n}"
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+		expectedOffset  int
+	}{
+		{"var", 1, 1, 0},
+		{"int", 1, 5, 4},
+		{"a", 1, 9, 8},
+		{"=", 1, 11, 10},
+		{"5", 1, 13, 12},
+		{";", 1, 14, 13},
+		{"if", 2, 2, 16},
+		{"(", 2, 5, 19},
+		{"a", 2, 6, 20},
+		{">", 2, 8, 22},
+		{"3", 2, 10, 24},
+		{")", 2, 11, 25},
+		{"{", 2, 13, 27},
+		{"done", 3, 3, 31},
+		{"}", 4, 1, 38},
+		{"", 4, 2, 39},
+	}
+
+	lex := NewFile("test.core", input)
+
+	for i, tt := range tests {
+		currentToken := lex.NextToken()
+
+		if currentToken.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong, expected=%q, got=%q",
+				i, tt.expectedLiteral, currentToken.Literal)
+		}
+
+		pos := currentToken.Pos
+		if pos.Filename != "test.core" {
+			t.Fatalf("test[%d] - filename wrong, expected=%q, got=%q",
+				i, "test.core", pos.Filename)
+		}
+
+		if pos.Line != tt.expectedLine || pos.Column != tt.expectedColumn {
+			t.Fatalf("test[%d] - position wrong, expected=%d:%d, got=%d:%d",
+				i, tt.expectedLine, tt.expectedColumn, pos.Line, pos.Column)
+		}
+
+		if pos.Offset != tt.expectedOffset {
+			t.Fatalf("test[%d] - offset wrong, expected=%d, got=%d",
+				i, tt.expectedOffset, pos.Offset)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+var int a = 5; // trailing
+/* block /* nested */ still comment */ a / 2;
+`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// leading comment"},
+		{token.VAR, "var"},
+		{token.INT_TYPE, "int"},
+		{token.IDENT, "a"},
+		{token.ASSIGN_OP, "="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.COMMENT, "// trailing"},
+		{token.COMMENT, "/* block /* nested */ still comment */"},
+		{token.IDENT, "a"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.END, ""},
+	}
+
+	lex := New(input)
+	lex.SetMode(ScanComments)
+
+	for i, tt := range tests {
+		currentToken := lex.NextToken()
+
+		if currentToken.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong, expected=%q, got=%q",
+				i, tt.expectedType, currentToken.Type)
+		}
+
+		if currentToken.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong, expected=%q, got=%q",
+				i, tt.expectedLiteral, currentToken.Literal)
+		}
+	}
+
+	if len(lex.Errors()) != 0 {
+		t.Fatalf("unexpected lexer errors: %v", lex.Errors())
+	}
+
+	lex = New(input)
+	for _, tt := range tests {
+		if tt.expectedType == token.COMMENT {
+			continue
+		}
+
+		currentToken := lex.NextToken()
+		if currentToken.Type != tt.expectedType {
+			t.Fatalf("comment not skipped, expected=%q, got=%q",
+				tt.expectedType, currentToken.Type)
+		}
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	input := "a /* never /* closed */"
+
+	lex := New(input)
+	lex.NextToken()
+
+	end := lex.NextToken()
+	if end.Type != token.END {
+		t.Fatalf("expected END after unterminated comment, got=%q", end.Type)
+	}
+
+	errors := lex.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got=%d", len(errors))
+	}
+
+	if errors[0].Message != "unterminated block comment" {
+		t.Errorf("wrong message. got=%q", errors[0].Message)
+	}
+
+	if errors[0].Span.Start.Column != 3 {
+		t.Errorf("wrong error column. expected=3, got=%d", errors[0].Span.Start.Column)
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := "var int größe = 1; 名前 + x1 + café_2 \"日本\" é"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.VAR, "var", 1},
+		{token.INT_TYPE, "int", 5},
+		{token.IDENT, "größe", 9},
+		{token.ASSIGN_OP, "=", 15},
+		{token.INT, "1", 17},
+		{token.SEMICOLON, ";", 18},
+		{token.IDENT, "名前", 20},
+		{token.PLUS, "+", 23},
+		{token.IDENT, "x1", 25},
+		{token.PLUS, "+", 28},
+		{token.IDENT, "café_2", 30},
+		{token.STRING, "日本", 37},
+		{token.IDENT, "é", 42},
+		{token.END, "", 43},
+	}
+
+	lex := New(input)
+
+	for i, tt := range tests {
+		currentToken := lex.NextToken()
+
+		if currentToken.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong, expected=%q, got=%q",
+				i, tt.expectedType, currentToken.Type)
+		}
+
+		if currentToken.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong, expected=%q, got=%q",
+				i, tt.expectedLiteral, currentToken.Literal)
+		}
+
+		if currentToken.Pos.Column != tt.expectedColumn {
+			t.Fatalf("test[%d] - column wrong, expected=%d, got=%d",
+				i, tt.expectedColumn, currentToken.Pos.Column)
+		}
+	}
+
+	if len(lex.Errors()) != 0 {
+		t.Fatalf("unexpected lexer errors: %v", lex.Errors())
+	}
+}
+
+func TestInvalidEncoding(t *testing.T) {
+	lex := New("a \xff b")
+
+	tests := []token.TokenType{token.IDENT, token.INVALID, token.IDENT, token.END}
+	for i, expected := range tests {
+		currentToken := lex.NextToken()
+		if currentToken.Type != expected {
+			t.Fatalf("test[%d] - tokentype wrong, expected=%q, got=%q",
+				i, expected, currentToken.Type)
+		}
+	}
+
+	if len(lex.Errors()) != 1 {
+		t.Fatalf("expected 1 lexer error, got=%d", len(lex.Errors()))
+	}
+
+	if lex.Errors()[0].Message != "invalid UTF-8 encoding (byte 0xff)" {
+		t.Errorf("wrong message. got=%q", lex.Errors()[0].Message)
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedValue string
+	}{
+		{`"plain"`, "plain"},
+		{`"tab\there\nnewline"`, "tab\there\nnewline"},
+		{`"quote \" and backslash \\"`, `quote " and backslash \`},
+		{`"\r\0\'"`, "\r\x00'"},
+		{`"caf\u00e9"`, "café"},
+		{`"\x41\U0001F600"`, "A😀"},
+		{"`raw \\n string`", `raw \n string`},
+		{"`multi\nline\n\tstring`", "multi\nline\n\tstring"},
+		{"`has \"quotes\"`", `has "quotes"`},
+	}
+
+	for i, tt := range tests {
+		lex := New(tt.input)
+		currentToken := lex.NextToken()
+
+		if currentToken.Type != token.STRING {
+			t.Fatalf("test[%d] - tokentype wrong, expected=%q, got=%q",
+				i, token.STRING, currentToken.Type)
+		}
+
+		if currentToken.Literal != tt.expectedValue {
+			t.Errorf("test[%d] - literal wrong, expected=%q, got=%q",
+				i, tt.expectedValue, currentToken.Literal)
+		}
+
+		if len(lex.Errors()) != 0 {
+			t.Errorf("test[%d] - unexpected errors: %v", i, lex.Errors())
+		}
+
+		if end := lex.NextToken(); end.Type != token.END {
+			t.Errorf("test[%d] - string not fully consumed, next token %q", i, end.Type)
+		}
+	}
+}
+
+func TestStringLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedColumn  int
+	}{
+		{`"never closed`, "unterminated string literal", 1},
+		{"a = \"broken\nline", "unterminated string literal", 5},
+		{"`raw never closed", "unterminated string literal", 1},
+		{`"bad \q escape"`, "unknown escape sequence \\q", 6},
+		{`"short \u12"`, "escape sequence \\u needs 4 hexadecimal digits", 8},
+		{`"\UFFFFFFFF"`, "escape sequence is not a valid Unicode code point", 2},
+		{`"trailing \`, "unterminated string literal", 1},
+	}
+
+	for i, tt := range tests {
+		lex := New(tt.input)
+		for currentToken := lex.NextToken(); currentToken.Type != token.END; currentToken = lex.NextToken() {
+		}
+
+		errors := lex.Errors()
+		if len(errors) != 1 {
+			t.Errorf("test[%d] - expected 1 error, got=%d %v", i, len(errors), errors)
+			continue
+		}
+
+		if errors[0].Message != tt.expectedMessage {
+			t.Errorf("test[%d] - wrong message, expected=%q, got=%q",
+				i, tt.expectedMessage, errors[0].Message)
+		}
+
+		if errors[0].Span.Start.Column != tt.expectedColumn {
+			t.Errorf("test[%d] - wrong column, expected=%d, got=%d",
+				i, tt.expectedColumn, errors[0].Span.Start.Column)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	input := `"hello ${name}, you are ${age + 1}!" "${ {"a": 1}["a"] }" "cost: \${x} $5"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.STRING_HEAD, "hello "},
+		{token.IDENT, "name"},
+		{token.STRING_MIDDLE, ", you are "},
+		{token.IDENT, "age"},
+		{token.PLUS, "+"},
+		{token.INT, "1"},
+		{token.STRING_TAIL, "!"},
+		{token.STRING_HEAD, ""},
+		{token.LEFT_CURLY_BRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.RIGHT_CURLY_BRACE, "}"},
+		{token.LEFT_BRACKET, "["},
+		{token.STRING, "a"},
+		{token.RIGHT_BRACKET, "]"},
+		{token.STRING_TAIL, ""},
+		{token.STRING, "cost: ${x} $5"},
+		{token.END, ""},
+	}
+
+	lex := New(input)
+
+	for i, tt := range tests {
+		currentToken := lex.NextToken()
+
+		if currentToken.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong, expected=%q, got=%q",
+				i, tt.expectedType, currentToken.Type)
+		}
+
+		if currentToken.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong, expected=%q, got=%q",
+				i, tt.expectedLiteral, currentToken.Literal)
+		}
+	}
+
+	if len(lex.Errors()) != 0 {
+		t.Fatalf("unexpected lexer errors: %v", lex.Errors())
+	}
+}
+
+func TestUnterminatedInterpolation(t *testing.T) {
+	inputs := []string{
+		`"value: ${x + 1`,
+		`var s = "${"`,
+		`"${ "${ "abc`,
+		"\"${ \"abc\n}",
+	}
+
+	for _, input := range inputs {
+		lex := New(input)
+		for currentToken := lex.NextToken(); currentToken.Type != token.END; currentToken = lex.NextToken() {
+		}
+
+		errors := lex.Errors()
+		if len(errors) != 1 || errors[0].Message != "unterminated string literal" {
+			t.Errorf("expected one unterminated string error for %q, got=%v", input, errors)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	input := "42 3.14 0.5 1e9 2.5E-3 7e+2 1.x 5. 10"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "42"},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "0.5"},
+		{token.FLOAT, "1e9"},
+		{token.FLOAT, "2.5E-3"},
+		{token.FLOAT, "7e+2"},
+		{token.INT, "1"},
+		{token.INVALID, "."},
+		{token.IDENT, "x"},
+		{token.INT, "5"},
+		{token.INVALID, "."},
+		{token.INT, "10"},
+		{token.END, ""},
+	}
+
+	lex := New(input)
+
+	for i, tt := range tests {
+		currentToken := lex.NextToken()
+
+		if currentToken.Type != tt.expectedType {
+			t.Fatalf("test[%d] - tokentype wrong, expected=%q, got=%q",
+				i, tt.expectedType, currentToken.Type)
+		}
+
+		if currentToken.Literal != tt.expectedLiteral {
+			t.Fatalf("test[%d] - literal wrong, expected=%q, got=%q",
+				i, tt.expectedLiteral, currentToken.Literal)
+		}
+	}
+
+	lex = New("1e+")
+	lex.NextToken()
+	if len(lex.Errors()) != 1 || lex.Errors()[0].Message != `exponent has no digits in "1e+"` {
+		t.Fatalf("expected a malformed exponent error, got=%v", lex.Errors())
+	}
+}
+
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedMessage string
+	}{
+		{"0xFF", token.INT, ""},
+		{"0Xdead_BEEF", token.INT, ""},
+		{"0o755", token.INT, ""},
+		{"0b1010", token.INT, ""},
+		{"0b_1010", token.INT, ""},
+		{"1_000_000", token.INT, ""},
+		{"0755", token.INT, ""},
+		{"1_000.000_1", token.FLOAT, ""},
+		{"6.02e2_3", token.FLOAT, ""},
+		{"0x", token.INT, `hexadecimal literal "0x" has no digits`},
+		{"0b102", token.INT, `invalid digit '2' in binary literal "0b102"`},
+		{"0o78", token.INT, `invalid digit '8' in octal literal "0o78"`},
+		{"0xFG", token.INT, `invalid digit 'G' in hexadecimal literal "0xFG"`},
+		{"089", token.INT, `invalid digit '8' in octal literal "089"`},
+		{"1__000", token.INT, `'_' must separate successive digits in "1__000"`},
+		{"1000_", token.INT, `'_' must separate successive digits in "1000_"`},
+		{"1_.5", token.FLOAT, `'_' must separate successive digits in "1_.5"`},
+		{"0x_", token.INT, `'_' must separate successive digits in "0x_"`},
+		{"2e", token.FLOAT, `exponent has no digits in "2e"`},
+	}
+
+	for i, tt := range tests {
+		lex := New(tt.input)
+		currentToken := lex.NextToken()
+
+		if currentToken.Type != tt.expectedType || currentToken.Literal != tt.input {
+			t.Errorf("test[%d] - token wrong, expected=%q %q, got=%q %q",
+				i, tt.expectedType, tt.input, currentToken.Type, currentToken.Literal)
+		}
+
+		if end := lex.NextToken(); end.Type != token.END {
+			t.Errorf("test[%d] - literal not fully consumed, next token %q", i, end.Literal)
+		}
+
+		errors := lex.Errors()
+		if tt.expectedMessage == "" {
+			if len(errors) != 0 {
+				t.Errorf("test[%d] - unexpected errors: %v", i, errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 || errors[0].Message != tt.expectedMessage {
+			t.Errorf("test[%d] - expected error %q, got=%v", i, tt.expectedMessage, errors)
+		}
+	}
+}
+
+func TestOperatorTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.TokenType
+	}{
+		{"a && b", []token.TokenType{token.IDENT, token.AND, token.IDENT}},
+		{"a || b", []token.TokenType{token.IDENT, token.OR, token.IDENT}},
+		{"!a&&b", []token.TokenType{token.BANG, token.IDENT, token.AND, token.IDENT}},
+		{"a % b", []token.TokenType{token.IDENT, token.PERCENT, token.IDENT}},
+		{"a & b | c ^ ~d", []token.TokenType{token.IDENT, token.BIT_AND, token.IDENT, token.BIT_OR,
+			token.IDENT, token.BIT_XOR, token.BIT_NOT, token.IDENT}},
+		{"a << b >> c", []token.TokenType{token.IDENT, token.SHIFT_LEFT, token.IDENT, token.SHIFT_RIGHT, token.IDENT}},
+		{"a<<=b", []token.TokenType{token.IDENT, token.SHIFT_LEFT, token.ASSIGN_OP, token.IDENT}},
+		{"a += b -= c *= d /= e", []token.TokenType{token.IDENT, token.PLUS_ASSIGN, token.IDENT,
+			token.MINUS_ASSIGN, token.IDENT, token.ASTERISK_ASSIGN, token.IDENT, token.SLASH_ASSIGN, token.IDENT}},
+		{"++a--", []token.TokenType{token.INCREMENT, token.IDENT, token.DECREMENT}},
+		{"k, v in h", []token.TokenType{token.IDENT, token.COMMA, token.IDENT, token.IN, token.IDENT}},
+		{"bool? | null", []token.TokenType{token.BOOL_TYPE, token.QUESTION, token.BIT_OR, token.NULL}},
+		{"a+ +b - -c", []token.TokenType{token.IDENT, token.PLUS, token.PLUS, token.IDENT,
+			token.MINUS, token.MINUS, token.IDENT}},
+		{"a ** b * c", []token.TokenType{token.IDENT, token.POWER, token.IDENT, token.ASTERISK, token.IDENT}},
+		{"a <= b >= c < d > e", []token.TokenType{token.IDENT, token.LESS_EQUAL, token.IDENT,
+			token.GREATER_EQUAL, token.IDENT, token.LESS_THEN, token.IDENT, token.GREATER_THEN, token.IDENT}},
+	}
+
+	for _, tt := range tests {
+		lex := New(tt.input)
+
+		for i, expectedType := range tt.expected {
+			currentToken := lex.NextToken()
+			if currentToken.Type != expectedType {
+				t.Errorf("%q: token[%d] wrong type. expected=%q, got=%q (%q)",
+					tt.input, i, expectedType, currentToken.Type, currentToken.Literal)
+			}
+		}
+
+		if currentToken := lex.NextToken(); currentToken.Type != token.END {
+			t.Errorf("%q: expected END, got=%q", tt.input, currentToken.Type)
+		}
+	}
+}
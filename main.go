@@ -1,13 +1,23 @@
 package main
 
 import (
+	"Go-Tutorials/Core-lang/diagnostic"
+	"Go-Tutorials/Core-lang/evaluator"
+	"Go-Tutorials/Core-lang/lexer"
+	"Go-Tutorials/Core-lang/object"
+	"Go-Tutorials/Core-lang/parser"
 	"Go-Tutorials/Core-lang/repl"
+	"Go-Tutorials/Core-lang/types"
 	"fmt"
 	"os"
 	"os/user"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(run(os.Args[1]))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -17,3 +27,36 @@ func main() {
 	fmt.Printf("You can type now\n")
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+// run parses, type-checks and evaluates a source file, and returns the exit
+// status of the process.
+func run(path string) int {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	par := parser.New(lexer.NewFile(path, string(source)))
+	program := par.ParseProgram()
+	if len(par.Errors()) != 0 {
+		fmt.Fprint(os.Stderr, diagnostic.RenderAll(string(source), par.Errors()))
+		return 1
+	}
+
+	if errors := types.Check(program); len(errors) != 0 {
+		fmt.Fprint(os.Stderr, diagnostic.RenderAll(string(source), errors))
+		return 1
+	}
+
+	evaluated := evaluator.Evaluate(program, object.NewEnvironment())
+	if evaluated, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, evaluated.Inspect())
+		return 1
+	}
+	if evaluated != nil {
+		fmt.Println(evaluated.Inspect())
+	}
+
+	return 0
+}
@@ -1,19 +1,43 @@
 package object
 
+import "Go-Tutorials/Core-lang/ast"
+
 type Environment struct {
 	store map[string]Object
+	types map[string]ast.TypeExpression // declared types of typed bindings
 	outer *Environment
+
+	// calls is the number of function calls in progress, shared by every
+	// environment of one evaluation
+	calls *int
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	environment := NewEnvironment()
 	environment.outer = outer
+	environment.calls = outer.calls
 	return environment
 }
 
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	t := make(map[string]ast.TypeExpression)
+	return &Environment{store: s, types: t, outer: nil, calls: new(int)}
+}
+
+// EnterCall records the start of a function call and reports whether fewer
+// than limit calls were already in progress. A call is only recorded when it
+// is allowed, and must then be ended with LeaveCall.
+func (env *Environment) EnterCall(limit int) bool {
+	if *env.calls >= limit {
+		return false
+	}
+	*env.calls++
+	return true
+}
+
+func (env *Environment) LeaveCall() {
+	*env.calls--
 }
 
 func (env *Environment) Get(name string) (Object, bool) {
@@ -26,5 +50,39 @@ func (env *Environment) Get(name string) (Object, bool) {
 
 func (env *Environment) Set(name string, value Object) Object {
 	env.store[name] = value
+	delete(env.types, name)
 	return value
 }
+
+// Declare binds name like Set and records the type it was declared with. A
+// nil type makes the binding untyped.
+func (env *Environment) Declare(name string, value Object, declared ast.TypeExpression) Object {
+	env.Set(name, value)
+	if declared != nil {
+		env.types[name] = declared
+	}
+	return value
+}
+
+// DeclaredType returns the declared type of the nearest binding of name, or
+// nil if that binding is untyped or does not exist.
+func (env *Environment) DeclaredType(name string) ast.TypeExpression {
+	for scope := env; scope != nil; scope = scope.outer {
+		if _, ok := scope.store[name]; ok {
+			return scope.types[name]
+		}
+	}
+	return nil
+}
+
+// Assign updates the nearest existing binding of name, walking outwards
+// through the enclosing scopes. It reports false if name is not bound.
+func (env *Environment) Assign(name string, value Object) bool {
+	for scope := env; scope != nil; scope = scope.outer {
+		if _, ok := scope.store[name]; ok {
+			scope.store[name] = value
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,9 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math/big"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -12,15 +15,19 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	STRING_OBJ       = "STRING"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 	ERROR_OBJ        = "ERROR"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	FUNCTION_OBJ     = "FUNCTION"
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	RANGE_OBJ        = "RANGE"
 )
 
 type Object interface {
@@ -36,6 +43,42 @@ type Integer struct {
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
+// BigInteger is the arbitrary-precision representation of an INTEGER. It is
+// only used for values outside the int64 range; arithmetic whose result fits
+// in an int64 produces an Integer again (see NewBigInteger).
+type BigInteger struct {
+	Value *big.Int
+}
+
+func (bi *BigInteger) Inspect() string  { return bi.Value.String() }
+func (bi *BigInteger) Type() ObjectType { return INTEGER_OBJ }
+
+// NewBigInteger returns value as an Integer when it fits in an int64 and as
+// a BigInteger otherwise, so every integer has a single representation.
+func NewBigInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+	return &BigInteger{Value: value}
+}
+
+// Float
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
+// Inspect always shows a fraction or exponent so floats are never mistaken
+// for integers, e.g. 3.0 instead of 3.
+func (f *Float) Inspect() string {
+	str := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eIN") {
+		str += ".0"
+	}
+	return str
+}
+
 // Boolean
 type Boolean struct {
 	Value bool
@@ -53,23 +96,24 @@ func (n *Null) Inspect() string  { return "null" }
 // Function
 
 type Function struct {
-	Parameters []*ast.Identifier
-	Body       *ast.BlockStatement
-	Env        *Environment
+	Name           string // empty for an anonymous function
+	Parameters     []*ast.Identifier
+	ParameterTypes []ast.TypeExpression // nil for an untyped parameter
+	ReturnType     ast.TypeExpression   // nil when the return type is not declared
+	Body           *ast.BlockStatement
+	Env            *Environment
 }
 
 func (fn *Function) Type() ObjectType { return FUNCTION_OBJ }
 func (fn *Function) Inspect() string {
 	var output bytes.Buffer
-	parameters := []string{}
-	for _, p := range fn.Parameters {
-		parameters = append(parameters, p.String())
-	}
 
 	output.WriteString("function")
-	output.WriteString("(")
-	output.WriteString(strings.Join(parameters, ", "))
-	output.WriteString(") {\n")
+	if fn.Name != "" {
+		output.WriteString(" " + fn.Name)
+	}
+	output.WriteString(ast.FormatSignature(fn.Parameters, fn.ParameterTypes, fn.ReturnType))
+	output.WriteString(" {\n")
 	output.WriteString(fn.Body.String())
 	output.WriteString("\n}")
 
@@ -83,6 +127,18 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+// Break and Continue are signals, like ReturnValue, that unwind the block
+// statements of a loop body up to the loop that handles them.
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
 // Error
 type Error struct {
 	Message string
@@ -101,7 +157,8 @@ type Builtin struct {
 func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 func (b *Builtin) Inspect() string  { return "builtin function" }
 
-// String
+// String holds UTF-8 text. The language treats strings as sequences of
+// Unicode code points: len and indexing count runes, not bytes.
 type String struct {
 	Value string
 }
@@ -135,6 +192,12 @@ type HashKey struct {
 	Value uint64
 }
 
+// bigIntegerKey is the HashKey type of big integers. Their values are hashes
+// rather than the integers themselves, so they need a key space apart from
+// that of Integer; as a BigInteger never fits in an int64, no big integer is
+// equal to an Integer.
+const bigIntegerKey ObjectType = "BIG_INTEGER"
+
 func (b *Boolean) HashKey() HashKey {
 	var value uint64
 
@@ -151,6 +214,14 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+func (bi *BigInteger) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte{byte(bi.Value.Sign() + 1)})
+	h.Write(bi.Value.Bytes())
+
+	return HashKey{Type: bigIntegerKey, Value: h.Sum64()}
+}
+
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
@@ -176,7 +247,7 @@ func (h *Hash) Inspect() string {
 	var output bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.SortedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s",
 			pair.Key.Inspect(), pair.Value.Inspect()))
 	}
@@ -187,3 +258,71 @@ func (h *Hash) Inspect() string {
 
 	return output.String()
 }
+
+// SortedPairs returns the pairs of the hash ordered by key: keys of
+// different types are ordered by type name, integers numerically, strings
+// by code point and false before true. Iterating a hash uses this order.
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return compareKeys(pairs[i].Key, pairs[j].Key) < 0
+	})
+
+	return pairs
+}
+
+func compareKeys(left, right Object) int {
+	if left.Type() != right.Type() {
+		return strings.Compare(string(left.Type()), string(right.Type()))
+	}
+
+	switch left := left.(type) {
+	case *String:
+		return strings.Compare(left.Value, right.(*String).Value)
+	case *Boolean:
+		rightValue := right.(*Boolean).Value
+		switch {
+		case left.Value == rightValue:
+			return 0
+		case rightValue:
+			return -1
+		default:
+			return 1
+		}
+	case *Integer, *BigInteger:
+		return integerValue(left).Cmp(integerValue(right))
+	default:
+		return 0
+	}
+}
+
+func integerValue(obj Object) *big.Int {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value)
+	case *BigInteger:
+		return obj.Value
+	default:
+		return new(big.Int)
+	}
+}
+
+// Range is the lazy sequence of integers produced by the range builtin:
+// Start, Start+Step, ... up to but excluding End.
+type Range struct {
+	Start int64
+	End   int64
+	Step  int64
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string {
+	if r.Step == 1 {
+		return fmt.Sprintf("range(%d, %d)", r.Start, r.End)
+	}
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.End, r.Step)
+}
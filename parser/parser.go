@@ -2,40 +2,78 @@ package parser
 
 import (
 	"Go-Tutorials/Core-lang/ast"
+	"Go-Tutorials/Core-lang/diagnostic"
 	"Go-Tutorials/Core-lang/lexer"
 	"Go-Tutorials/Core-lang/token"
-	"fmt"
+	"errors"
+	"math/big"
 	"strconv"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // = or +=
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
+	BIT_OR      // |
+	BIT_XOR     // ^
+	BIT_AND     // &
 	EQUALS      // ==
 	LESSGREATER // > or <
+	SHIFT       // << or >>
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X OR !X
+	POWER       // **
+	POSTFIX     // X++ or X--
 	CALL        // myFunction(x)
 	INDEX       // array[index]
 )
 
 var precedences = map[token.TokenType]int{
+	token.ASSIGN_OP:        ASSIGN,
+	token.PLUS_ASSIGN:      ASSIGN,
+	token.MINUS_ASSIGN:     ASSIGN,
+	token.ASTERISK_ASSIGN:  ASSIGN,
+	token.SLASH_ASSIGN:     ASSIGN,
+	token.OR:               LOGICAL_OR,
+	token.AND:              LOGICAL_AND,
+	token.BIT_OR:           BIT_OR,
+	token.BIT_XOR:          BIT_XOR,
+	token.BIT_AND:          BIT_AND,
 	token.EQ:               EQUALS,
 	token.NOT_EQ:           EQUALS,
 	token.LESS_THEN:        LESSGREATER,
 	token.GREATER_THEN:     LESSGREATER,
+	token.LESS_EQUAL:       LESSGREATER,
+	token.GREATER_EQUAL:    LESSGREATER,
+	token.SHIFT_LEFT:       SHIFT,
+	token.SHIFT_RIGHT:      SHIFT,
 	token.PLUS:             SUM,
 	token.MINUS:            SUM,
 	token.SLASH:            PRODUCT,
 	token.ASTERISK:         PRODUCT,
+	token.PERCENT:          PRODUCT,
+	token.POWER:            POWER,
+	token.INCREMENT:        POSTFIX,
+	token.DECREMENT:        POSTFIX,
 	token.LEFT_PARANTHESIS: CALL,
 	token.LEFT_BRACKET:     INDEX,
 }
 
 type Parser struct {
 	lex    *lexer.Lexer
-	errors []string
+	errors []*diagnostic.Diagnostic
+
+	// panicking is set after a syntax error and cleared by synchronize;
+	// errors reported in between are follow-on errors and are dropped.
+	panicking bool
+
+	lexErrorCount int // lexer errors already copied into errors
+	comments      []*ast.Comment
+
+	loopDepth int // number of loops enclosing the current statement
 
 	currentToken token.Token
 	peekToken    token.Token
@@ -52,20 +90,32 @@ type (
 func New(lex *lexer.Lexer) *Parser {
 	par := &Parser{
 		lex:    lex,
-		errors: []string{},
+		errors: []*diagnostic.Diagnostic{},
 	}
 
 	par.prefixParseFunction = make(map[token.TokenType]prefixParseFunction)
 	par.registerPrefix(token.IDENT, par.parseIdentifier)
 	par.registerPrefix(token.INT, par.parseIntegerLiteral)
+	par.registerPrefix(token.FLOAT, par.parseFloatLiteral)
+	// type names double as the conversion builtins int(x), float(x) and string(x)
+	par.registerPrefix(token.INT_TYPE, par.parseIdentifier)
+	par.registerPrefix(token.FLOAT_TYPE, par.parseIdentifier)
+	par.registerPrefix(token.STRING_TYPE, par.parseIdentifier)
 	par.registerPrefix(token.BANG, par.parsePrefixExpression)
 	par.registerPrefix(token.MINUS, par.parsePrefixExpression)
+	par.registerPrefix(token.BIT_NOT, par.parsePrefixExpression)
+	par.registerPrefix(token.INCREMENT, par.parsePrefixUpdateExpression)
+	par.registerPrefix(token.DECREMENT, par.parsePrefixUpdateExpression)
 	par.registerPrefix(token.TRUE, par.parseBoolean)
 	par.registerPrefix(token.FALSE, par.parseBoolean)
+	par.registerPrefix(token.NULL, par.parseNullLiteral)
 	par.registerPrefix(token.LEFT_PARANTHESIS, par.parseParenthesizedExpression)
 	par.registerPrefix(token.IF, par.parseIfExpression)
 	par.registerPrefix(token.FUNCTION, par.parseFunctionLiteral)
 	par.registerPrefix(token.STRING, par.parseStringLiteral)
+	par.registerPrefix(token.STRING_HEAD, par.parseInterpolatedString)
+	par.registerPrefix(token.LEFT_BRACKET, par.parseArrayLiteral)
+	par.registerPrefix(token.LEFT_CURLY_BRACE, par.parseHashLiteral)
 
 	par.infixParseFunction = make(map[token.TokenType]infixParseFunction)
 	par.registerInfix(token.PLUS, par.parseInfixExpression)
@@ -76,7 +126,27 @@ func New(lex *lexer.Lexer) *Parser {
 	par.registerInfix(token.NOT_EQ, par.parseInfixExpression)
 	par.registerInfix(token.LESS_THEN, par.parseInfixExpression)
 	par.registerInfix(token.GREATER_THEN, par.parseInfixExpression)
+	par.registerInfix(token.LESS_EQUAL, par.parseInfixExpression)
+	par.registerInfix(token.GREATER_EQUAL, par.parseInfixExpression)
+	par.registerInfix(token.PERCENT, par.parseInfixExpression)
+	par.registerInfix(token.POWER, par.parseInfixExpression)
+	par.registerInfix(token.BIT_AND, par.parseInfixExpression)
+	par.registerInfix(token.BIT_OR, par.parseInfixExpression)
+	par.registerInfix(token.BIT_XOR, par.parseInfixExpression)
+	par.registerInfix(token.SHIFT_LEFT, par.parseInfixExpression)
+	par.registerInfix(token.SHIFT_RIGHT, par.parseInfixExpression)
 	par.registerInfix(token.SLASH, par.parseInfixExpression)
+	par.registerInfix(token.ASSIGN_OP, par.parseAssignExpression)
+	par.registerInfix(token.PLUS_ASSIGN, par.parseAssignExpression)
+	par.registerInfix(token.MINUS_ASSIGN, par.parseAssignExpression)
+	par.registerInfix(token.ASTERISK_ASSIGN, par.parseAssignExpression)
+	par.registerInfix(token.SLASH_ASSIGN, par.parseAssignExpression)
+	par.registerInfix(token.INCREMENT, par.parsePostfixUpdateExpression)
+	par.registerInfix(token.DECREMENT, par.parsePostfixUpdateExpression)
+	par.registerInfix(token.AND, par.parseLogicalExpression)
+	par.registerInfix(token.OR, par.parseLogicalExpression)
+	par.registerInfix(token.LEFT_PARANTHESIS, par.parseCallExpression)
+	par.registerInfix(token.LEFT_BRACKET, par.parseIndexExpression)
 
 	par.nextToken()
 	par.nextToken()
@@ -87,6 +157,19 @@ func New(lex *lexer.Lexer) *Parser {
 func (par *Parser) nextToken() {
 	par.currentToken = par.peekToken
 	par.peekToken = par.lex.NextToken()
+
+	for par.peekToken.Type == token.COMMENT {
+		par.comments = append(par.comments, &ast.Comment{Token: par.peekToken})
+		par.peekToken = par.lex.NextToken()
+	}
+
+	// A lexer error is the real mistake in the statement being parsed, so
+	// whatever the parser would report next is a follow-on error.
+	if lexErrors := par.lex.Errors(); len(lexErrors) > par.lexErrorCount {
+		par.errors = append(par.errors, lexErrors[par.lexErrorCount:]...)
+		par.lexErrorCount = len(lexErrors)
+		par.panicking = true
+	}
 }
 
 func (par *Parser) ParseProgram() *ast.Program {
@@ -95,33 +178,256 @@ func (par *Parser) ParseProgram() *ast.Program {
 
 	for par.currentToken.Type != token.END {
 		statement := par.parseStatement()
-		if statement != nil {
+		if par.panicking {
+			par.synchronize()
+		} else if statement != nil {
 			program.Statements = append(program.Statements, statement)
 		}
 		par.nextToken()
 	}
 
+	program.Comments = par.comments
 	return program
 }
 
+// synchronize skips tokens after a syntax error until the end of the broken
+// statement: a ';' or an unmatched '}', or just before a token that starts a
+// new statement. Braces opened while skipping are matched so that a broken
+// statement containing a block is skipped as a whole.
+func (par *Parser) synchronize() {
+	depth := 0
+
+	for !par.currentTokenIs(token.END) {
+		switch par.currentToken.Type {
+		case token.LEFT_CURLY_BRACE:
+			depth++
+		case token.RIGHT_CURLY_BRACE:
+			if depth == 0 {
+				par.panicking = false
+				return
+			}
+			depth--
+		case token.SEMICOLON:
+			if depth == 0 {
+				par.panicking = false
+				return
+			}
+		}
+
+		if depth == 0 {
+			switch par.peekToken.Type {
+			case token.VAR, token.RETURN, token.FUNCTION, token.WHILE, token.FOR,
+				token.RIGHT_CURLY_BRACE:
+				par.panicking = false
+				return
+			}
+		}
+
+		par.nextToken()
+	}
+
+	par.panicking = false
+}
+
 func (par *Parser) parseStatement() ast.Statement {
 	switch par.currentToken.Type {
 	case token.VAR:
 		return par.parseVarStatement()
 	case token.RETURN:
 		return par.parseReturnStatement()
+	case token.FUNCTION:
+		if par.peekedTokenIs(token.IDENT) {
+			return par.parseFunctionStatement()
+		}
+		return par.parseExpressionStatement()
+	case token.WHILE:
+		return par.parseWhileStatement()
+	case token.FOR:
+		return par.parseForStatement()
+	case token.BREAK:
+		return par.parseBreakStatement()
+	case token.CONTINUE:
+		return par.parseContinueStatement()
 	default:
 		return par.parseExpressionStatement()
 	}
 }
 
+func (par *Parser) parseWhileStatement() *ast.WhileStatement {
+	statement := &ast.WhileStatement{Token: par.currentToken}
+
+	if !par.ensureNext(token.LEFT_PARANTHESIS) {
+		return nil
+	}
+
+	par.nextToken()
+	statement.Condition = par.parseExpression(LOWEST)
+
+	if !par.ensureNext(token.RIGHT_PARANTHESIS) {
+		return nil
+	}
+
+	if !par.ensureNext(token.LEFT_CURLY_BRACE) {
+		return nil
+	}
+
+	statement.Body = par.parseLoopBody()
+
+	return statement
+}
+
+func (par *Parser) parseForStatement() ast.Statement {
+	statement := &ast.ForStatement{Token: par.currentToken}
+
+	if !par.ensureNext(token.LEFT_PARANTHESIS) {
+		return nil
+	}
+
+	par.nextToken()
+	if par.currentTokenIs(token.IDENT) &&
+		(par.peekedTokenIs(token.IN) || par.peekedTokenIs(token.COMMA)) {
+		return par.parseForInStatement(statement.Token)
+	}
+
+	switch {
+	case par.currentTokenIs(token.SEMICOLON):
+		// no init statement
+	case par.currentTokenIs(token.VAR):
+		// parseVarStatement consumes the semicolon itself
+		if init := par.parseVarStatement(); init != nil {
+			statement.Init = init
+		} else {
+			return nil
+		}
+	default:
+		init := &ast.ExpressionStatement{Token: par.currentToken}
+		init.Expression = par.parseExpression(LOWEST)
+		statement.Init = init
+
+		if !par.ensureNext(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	if !par.peekedTokenIs(token.SEMICOLON) {
+		par.nextToken()
+		statement.Condition = par.parseExpression(LOWEST)
+	}
+
+	if !par.ensureNext(token.SEMICOLON) {
+		return nil
+	}
+
+	if !par.peekedTokenIs(token.RIGHT_PARANTHESIS) {
+		par.nextToken()
+		statement.Post = par.parseExpression(LOWEST)
+	}
+
+	if !par.ensureNext(token.RIGHT_PARANTHESIS) {
+		return nil
+	}
+
+	if !par.ensureNext(token.LEFT_CURLY_BRACE) {
+		return nil
+	}
+
+	statement.Body = par.parseLoopBody()
+
+	return statement
+}
+
+// parseForInStatement continues a for statement whose "(" is followed by
+// "x in" or "k, v in"; the current token is the first variable.
+func (par *Parser) parseForInStatement(forToken token.Token) ast.Statement {
+	statement := &ast.ForInStatement{Token: forToken}
+	statement.Value = &ast.Identifier{Token: par.currentToken, Value: par.currentToken.Literal}
+
+	if par.peekedTokenIs(token.COMMA) {
+		par.nextToken()
+		if !par.ensureNext(token.IDENT) {
+			return nil
+		}
+		statement.Key = statement.Value
+		statement.Value = &ast.Identifier{Token: par.currentToken, Value: par.currentToken.Literal}
+	}
+
+	if !par.ensureNext(token.IN) {
+		return nil
+	}
+
+	par.nextToken()
+	statement.Iterable = par.parseExpression(LOWEST)
+
+	if !par.ensureNext(token.RIGHT_PARANTHESIS) {
+		return nil
+	}
+
+	if !par.ensureNext(token.LEFT_CURLY_BRACE) {
+		return nil
+	}
+
+	statement.Body = par.parseLoopBody()
+
+	return statement
+}
+
+func (par *Parser) parseLoopBody() *ast.BlockStatement {
+	par.loopDepth++
+	body := par.parseBlockStatement()
+	par.loopDepth--
+
+	if par.peekedTokenIs(token.SEMICOLON) {
+		par.nextToken()
+	}
+
+	return body
+}
+
+func (par *Parser) parseBreakStatement() *ast.BreakStatement {
+	statement := &ast.BreakStatement{Token: par.currentToken}
+	par.checkInsideLoop()
+
+	if !par.ensureNext(token.SEMICOLON) {
+		return nil
+	}
+
+	return statement
+}
+
+func (par *Parser) parseContinueStatement() *ast.ContinueStatement {
+	statement := &ast.ContinueStatement{Token: par.currentToken}
+	par.checkInsideLoop()
+
+	if !par.ensureNext(token.SEMICOLON) {
+		return nil
+	}
+
+	return statement
+}
+
+func (par *Parser) checkInsideLoop() {
+	if par.loopDepth == 0 {
+		par.errorAt(diagnostic.OutsideLoop, diagnostic.TokenSpan(par.currentToken),
+			"%s outside of a loop", par.currentToken.Literal)
+	}
+}
+
 func (par *Parser) parseVarStatement() *ast.VarStatement {
 	statement := &ast.VarStatement{Token: par.currentToken}
 
-	if !par.expectNextType() {
-		return nil
+	// the type is optional; type names are keywords, so an identifier can
+	// only be the variable name
+	var declaredType ast.TypeExpression
+	if !par.peekedTokenIs(token.IDENT) {
+		if !isTypeStart(par.peekToken.Type) {
+			par.errorAt(diagnostic.UnexpectedToken, diagnostic.TokenSpan(par.peekToken),
+				"expected next token to be - IDENT or type, got - %s instead", par.peekToken.Type)
+			return nil
+		}
+		if declaredType = par.expectNextType(); declaredType == nil {
+			return nil
+		}
 	}
-	declaredType := par.currentToken.Literal
 
 	if !par.ensureNext(token.IDENT) {
 		return nil
@@ -139,12 +445,6 @@ func (par *Parser) parseVarStatement() *ast.VarStatement {
 		return nil
 	}
 
-	valueType := par.resolveExpressionType(statement.Value)
-	if declaredType != valueType {
-		errMsg := fmt.Sprintf("Type mismatch: cannot assign %s to %s variable", valueType, declaredType)
-		par.errors = append(par.errors, errMsg)
-	}
-
 	if !par.ensureNext(token.SEMICOLON) {
 		return nil
 	}
@@ -153,17 +453,6 @@ func (par *Parser) parseVarStatement() *ast.VarStatement {
 	return statement
 }
 
-func (par *Parser) resolveExpressionType(expr ast.Expression) string {
-	switch expr.(type) {
-	case *ast.IntegerLiteral:
-		return "int"
-	case *ast.StringLiteral:
-		return "string"
-	default:
-		return "uknown"
-	}
-}
-
 func (par *Parser) currentTokenIs(tok token.TokenType) bool {
 	return par.currentToken.Type == tok
 }
@@ -181,14 +470,31 @@ func (par *Parser) ensureNext(tok token.TokenType) bool {
 	}
 }
 
-func (par *Parser) Errors() []string {
+func (par *Parser) Errors() []*diagnostic.Diagnostic {
 	return par.errors
 }
 
+// errorAt records a syntax error diagnostic for the given span and returns it
+// so callers can attach hints. The parser then stays in panic mode until the
+// next statement boundary, and errors reported meanwhile are discarded.
+func (par *Parser) errorAt(code string, span diagnostic.Span, format string, a ...interface{}) *diagnostic.Diagnostic {
+	diag := diagnostic.New(code, span, format, a...)
+	if par.panicking {
+		return diag
+	}
+
+	par.panicking = true
+	par.errors = append(par.errors, diag)
+	return diag
+}
+
 func (par *Parser) peekUnexpectedError(tok token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be - %s, got - %s instead",
-		tok, par.peekToken.Type)
-	par.errors = append(par.errors, msg)
+	diag := par.errorAt(diagnostic.UnexpectedToken, diagnostic.TokenSpan(par.peekToken),
+		"expected next token to be - %s, got - %s instead", tok, par.peekToken.Type)
+
+	if tok == token.SEMICOLON {
+		diag.WithHint("statements must end with ';'")
+	}
 }
 
 func (par *Parser) parseReturnStatement() *ast.ReturnStatement {
@@ -264,10 +570,16 @@ func (par *Parser) parseIdentifier() ast.Expression {
 func (par *Parser) parseIntegerLiteral() ast.Expression {
 	literal := &ast.IntegerLiteral{Token: par.currentToken}
 
+	// base 0 accepts the 0x, 0o and 0b prefixes and digit separators
 	value, err := strconv.ParseInt(par.currentToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		// integers are arbitrary-precision, so the literal is still valid
+		literal.Big, _ = new(big.Int).SetString(par.currentToken.Literal, 0)
+		return literal
+	}
 	if err != nil {
-		message := fmt.Sprintf("could not parse %q as integer", par.currentToken.Literal)
-		par.errors = append(par.errors, message)
+		par.errorAt(diagnostic.InvalidInteger, diagnostic.TokenSpan(par.currentToken),
+			"could not parse %q as integer", par.currentToken.Literal)
 		return nil
 	}
 
@@ -276,23 +588,24 @@ func (par *Parser) parseIntegerLiteral() ast.Expression {
 	return literal
 }
 
-func (par *Parser) expectNextType() bool {
-	types := map[token.TokenType]bool{
-		token.INT_TYPE:    true,
-		token.STRING_TYPE: true,
-	}
-	if types[par.peekToken.Type] {
-		par.nextToken()
-		return true
-	} else {
-		par.peekUnexpectedError(token.INT_TYPE)
-		return false
+func (par *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{Token: par.currentToken}
+
+	value, err := strconv.ParseFloat(par.currentToken.Literal, 64)
+	if err != nil {
+		par.errorAt(diagnostic.InvalidFloat, diagnostic.TokenSpan(par.currentToken),
+			"could not parse %q as float", par.currentToken.Literal)
+		return nil
 	}
+
+	literal.Value = value
+
+	return literal
 }
 
 func (par *Parser) singnalPrefixParseFnNotFound(tok token.TokenType) {
-	message := fmt.Sprintf("no prefix parse function for %s found", tok)
-	par.errors = append(par.errors, message)
+	par.errorAt(diagnostic.NoPrefixParse, diagnostic.TokenSpan(par.currentToken),
+		"no prefix parse function for %s found", tok)
 }
 
 func (par *Parser) parsePrefixExpression() ast.Expression {
@@ -332,16 +645,117 @@ func (par *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := par.currentPrecedence()
+	if precedence == POWER {
+		// ** is right-associative: a ** b ** c is a ** (b ** c)
+		precedence--
+	}
 	par.nextToken()
 	expression.Right = par.parseExpression(precedence)
 
 	return expression
 }
 
+func (par *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    par.currentToken,
+		Operator: par.currentToken.Literal,
+		Target:   target,
+	}
+	par.checkAssignTarget(expression.Token, target)
+
+	// assignment is right-associative: a = b = c is a = (b = c)
+	par.nextToken()
+	expression.Value = par.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
+func (par *Parser) parsePrefixUpdateExpression() ast.Expression {
+	expression := &ast.UpdateExpression{
+		Token:    par.currentToken,
+		Operator: par.currentToken.Literal,
+		Prefix:   true,
+	}
+
+	par.nextToken()
+	expression.Target = par.parseExpression(POSTFIX)
+	par.checkAssignTarget(expression.Token, expression.Target)
+
+	return expression
+}
+
+func (par *Parser) parsePostfixUpdateExpression(target ast.Expression) ast.Expression {
+	expression := &ast.UpdateExpression{
+		Token:    par.currentToken,
+		Operator: par.currentToken.Literal,
+		Target:   target,
+	}
+	par.checkAssignTarget(expression.Token, target)
+
+	return expression
+}
+
+// checkAssignTarget reports an error unless target is a variable or an
+// index expression, the only things a value can be stored into.
+func (par *Parser) checkAssignTarget(operator token.Token, target ast.Expression) {
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+		return
+	case nil:
+		return // the missing operand has already been reported
+	}
+
+	par.errorAt(diagnostic.InvalidTarget, diagnostic.TokenSpan(operator),
+		"invalid assignment target: %s", target.String())
+}
+
+func (par *Parser) parseLogicalExpression(left ast.Expression) ast.Expression {
+	expression := &ast.LogicalExpression{
+		Token:    par.currentToken,
+		Operator: par.currentToken.Literal,
+		Left:     left,
+	}
+
+	precedence := par.currentPrecedence()
+	par.nextToken()
+	expression.Right = par.parseExpression(precedence)
+
+	return expression
+}
+
+func (par *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: par.currentToken}
+}
+
 func (par *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: par.currentToken, Value: par.currentToken.Literal}
 }
 
+func (par *Parser) parseInterpolatedString() ast.Expression {
+	expression := &ast.InterpolatedString{
+		Token:   par.currentToken,
+		Strings: []string{par.currentToken.Literal},
+	}
+
+	for {
+		par.nextToken()
+		expression.Expressions = append(expression.Expressions, par.parseExpression(LOWEST))
+
+		switch {
+		case par.peekedTokenIs(token.STRING_MIDDLE):
+			par.nextToken()
+			expression.Strings = append(expression.Strings, par.currentToken.Literal)
+		case par.peekedTokenIs(token.STRING_TAIL):
+			par.nextToken()
+			expression.Strings = append(expression.Strings, par.currentToken.Literal)
+			return expression
+		default:
+			par.peekUnexpectedError(token.STRING_TAIL)
+			return nil
+		}
+	}
+}
+
 func (par *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: par.currentToken, Value: par.currentTokenIs(token.TRUE)}
 }
@@ -399,7 +813,12 @@ func (par *Parser) parseBlockStatement() *ast.BlockStatement {
 
 	for !par.currentTokenIs(token.RIGHT_CURLY_BRACE) && !par.currentTokenIs(token.END) {
 		statement := par.parseStatement()
-		if statement != nil {
+		if par.panicking {
+			par.synchronize()
+			if par.currentTokenIs(token.RIGHT_CURLY_BRACE) {
+				break
+			}
+		} else if statement != nil {
 			block.Statements = append(block.Statements, statement)
 		}
 		par.nextToken()
@@ -408,47 +827,189 @@ func (par *Parser) parseBlockStatement() *ast.BlockStatement {
 	return block
 }
 
+func (par *Parser) parseFunctionStatement() *ast.FunctionStatement {
+	statement := &ast.FunctionStatement{Token: par.currentToken}
+
+	par.nextToken()
+	statement.Name = &ast.Identifier{Token: par.currentToken, Value: par.currentToken.Literal}
+
+	literal := &ast.FunctionLiteral{Token: statement.Token, Name: statement.Name.Value}
+	if !par.parseFunction(literal) {
+		return nil
+	}
+	statement.Function = literal
+
+	if par.peekedTokenIs(token.SEMICOLON) {
+		par.nextToken()
+	}
+
+	return statement
+}
+
 func (par *Parser) parseFunctionLiteral() ast.Expression {
 	literal := &ast.FunctionLiteral{Token: par.currentToken}
+	if !par.parseFunction(literal) {
+		return nil
+	}
 
+	return literal
+}
+
+// parseFunction parses the parameter list and body that follow "function"
+// or the name of a declared function.
+func (par *Parser) parseFunction(literal *ast.FunctionLiteral) bool {
 	if !par.ensureNext(token.LEFT_PARANTHESIS) {
-		return nil
+		return false
 	}
 
-	literal.Parameters = par.parseFunctionParameters()
+	literal.Parameters, literal.ParameterTypes = par.parseFunctionParameters()
+	if literal.Parameters == nil {
+		return false
+	}
+
+	if par.peekedTokenIs(token.COLON) {
+		par.nextToken()
+		literal.ReturnType = par.expectNextType()
+		if literal.ReturnType == nil {
+			return false
+		}
+	}
 
 	if !par.ensureNext(token.LEFT_CURLY_BRACE) {
-		return nil
+		return false
 	}
 
+	// a loop around the function literal does not extend into its body
+	loopDepth := par.loopDepth
+	par.loopDepth = 0
 	literal.Body = par.parseBlockStatement()
+	par.loopDepth = loopDepth
 
-	return literal
+	return true
 }
 
-func (par *Parser) parseFunctionParameters() []*ast.Identifier {
+func (par *Parser) parseFunctionParameters() ([]*ast.Identifier, []ast.TypeExpression) {
 	identifiers := []*ast.Identifier{}
+	types := []ast.TypeExpression{}
 
 	if par.peekedTokenIs(token.RIGHT_PARANTHESIS) {
 		par.nextToken()
-		return identifiers
+		return identifiers, types
 	}
 
-	par.nextToken()
+	for {
+		ident, parameterType := par.parseParameter()
+		if ident == nil {
+			return nil, nil
+		}
+		identifiers = append(identifiers, ident)
+		types = append(types, parameterType)
 
-	ident := &ast.Identifier{Token: par.currentToken, Value: par.currentToken.Literal}
-	identifiers = append(identifiers, ident)
+		if !par.peekedTokenIs(token.COMMA) {
+			break
+		}
+		par.nextToken()
+	}
+
+	if !par.ensureNext(token.RIGHT_PARANTHESIS) {
+		return nil, nil
+	}
+
+	return identifiers, types
+}
+
+// parseParameter parses the next "name" or "type name" of a parameter list.
+func (par *Parser) parseParameter() (*ast.Identifier, ast.TypeExpression) {
+	var parameterType ast.TypeExpression
+	if isTypeStart(par.peekToken.Type) {
+		if parameterType = par.expectNextType(); parameterType == nil {
+			return nil, nil
+		}
+	}
+
+	if !par.ensureNext(token.IDENT) {
+		return nil, nil
+	}
+
+	return &ast.Identifier{Token: par.currentToken, Value: par.currentToken.Literal}, parameterType
+}
+
+func (par *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	expression := &ast.CallExpression{Token: par.currentToken, Function: function}
+	expression.Arguments = par.parseExpressionList(token.RIGHT_PARANTHESIS)
+	return expression
+}
+
+func (par *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: par.currentToken}
+	array.Elements = par.parseExpressionList(token.RIGHT_BRACKET)
+	return array
+}
+
+// parseExpressionList parses comma separated expressions up to and including
+// the end token.
+func (par *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if par.peekedTokenIs(end) {
+		par.nextToken()
+		return list
+	}
+
+	par.nextToken()
+	list = append(list, par.parseExpression(LOWEST))
 
 	for par.peekedTokenIs(token.COMMA) {
 		par.nextToken()
 		par.nextToken()
-		ident := &ast.Identifier{Token: par.currentToken, Value: par.currentToken.Literal}
-		identifiers = append(identifiers, ident)
+		list = append(list, par.parseExpression(LOWEST))
 	}
 
-	if !par.ensureNext(token.RIGHT_PARANTHESIS) {
+	if !par.ensureNext(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (par *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{Token: par.currentToken, Left: left}
+
+	par.nextToken()
+	expression.Index = par.parseExpression(LOWEST)
+
+	if !par.ensureNext(token.RIGHT_BRACKET) {
+		return nil
+	}
+
+	return expression
+}
+
+func (par *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: par.currentToken}
+	hash.Pairs = make(map[ast.Expression]ast.Expression)
+
+	for !par.peekedTokenIs(token.RIGHT_CURLY_BRACE) {
+		par.nextToken()
+		key := par.parseExpression(LOWEST)
+
+		if !par.ensureNext(token.COLON) {
+			return nil
+		}
+
+		par.nextToken()
+		value := par.parseExpression(LOWEST)
+
+		hash.Pairs[key] = value
+
+		if !par.peekedTokenIs(token.RIGHT_CURLY_BRACE) && !par.ensureNext(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !par.ensureNext(token.RIGHT_CURLY_BRACE) {
 		return nil
 	}
 
-	return identifiers
+	return hash
 }
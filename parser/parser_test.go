@@ -2,8 +2,10 @@ package parser
 
 import (
 	"Go-Tutorials/Core-lang/ast"
+	"Go-Tutorials/Core-lang/diagnostic"
 	"Go-Tutorials/Core-lang/lexer"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -12,6 +14,7 @@ func TestVarStatements(t *testing.T) {
 	var int b = 5;
 	var string a = "abc";
 	var int foobar = 123456;
+	var float pi = 3.14;
 	`
 
 	lex := lexer.New(input)
@@ -24,8 +27,8 @@ func TestVarStatements(t *testing.T) {
 	program := par.ParseProgram()
 	checkParserErrors(t, par)
 
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	if len(program.Statements) != 4 {
+		t.Fatalf("program.Statements does not contain 4 statements. got=%d", len(program.Statements))
 	}
 
 	tests := []struct {
@@ -35,6 +38,7 @@ func TestVarStatements(t *testing.T) {
 		{"b", "int"},
 		{"a", "string"},
 		{"foobar", "int"},
+		{"pi", "float"},
 	}
 
 	for i, tt := range tests {
@@ -67,7 +71,7 @@ func testVarStatement(t *testing.T, s ast.Statement, expectedName string, expect
 		return false
 	}
 
-	if varStatement.Type != expectedType {
+	if varStatement.Type.String() != expectedType {
 		t.Errorf("varStatement.Type not '%s', got=%s", expectedType, varStatement.Type)
 		return false
 	}
@@ -75,36 +79,157 @@ func testVarStatement(t *testing.T, s ast.Statement, expectedName string, expect
 	return true
 }
 
-func TestInvalidVarStatements(t *testing.T) {
+func TestUntypedVarStatements(t *testing.T) {
 	tests := []struct {
-		input       string
-		expectedMsg string
+		input    string
+		expected string
 	}{
-		{"var string b = 5;", "Type mismatch: cannot assign int to string variable"},
-		{"var int a = \"abc\";", "Type mismatch: cannot assign string to int variable"},
+		{"var x = 5;", "var x = 5;"},
+		{"var name = \"abc\" + y;", "var name = (abc + y);"},
+		{"var f = function(a) { a };", "var f = function(a) a;"},
+		{"for (var i = 0; i < 3; i++) { }", "for (var i = 0; (i < 3); (i++)) "},
 	}
 
 	for _, tt := range tests {
-		lex := lexer.New(tt.input)
-		par := New(lex)
-		_ = par.ParseProgram()
+		par := New(lexer.New(tt.input))
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
 
-		if len(par.Errors()) == 0 {
-			t.Fatalf("Expected type mismatch error but got none")
+		if varStatement, ok := program.Statements[0].(*ast.VarStatement); ok && varStatement.Type != nil {
+			t.Errorf("varStatement.Type not nil. got=%s", varStatement.Type)
 		}
 
-		isError := false
-		for _, message := range par.Errors() {
-			if message == tt.expectedMsg {
-				isError = true
-				break
-			}
+		if program.String() != tt.expected {
+			t.Errorf("wrong program. expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := `var int a = 5;
+return a * (b + 10);`
+
+	lex := lexer.NewFile("main.core", input)
+	par := New(lex)
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	varStatement := program.Statements[0].(*ast.VarStatement)
+	returnStatement := program.Statements[1].(*ast.ReturnStatement)
+	product := returnStatement.ReturnValue.(*ast.InfixExpression)
+	sum := product.Right.(*ast.InfixExpression)
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{program, "main.core:1:1"},
+		{varStatement, "main.core:1:1"},
+		{varStatement.Name, "main.core:1:9"},
+		{varStatement.Value, "main.core:1:13"},
+		{returnStatement, "main.core:2:1"},
+		{product, "main.core:2:8"},
+		{sum, "main.core:2:13"},
+		{sum.Right, "main.core:2:17"},
+	}
+
+	for i, tt := range tests {
+		if tt.node.Pos().String() != tt.expected {
+			t.Errorf("tests[%d] - position of %q wrong. expected=%s, got=%s",
+				i, tt.node.String(), tt.expected, tt.node.Pos())
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	input := `var int a = 5;
+var int b 6;`
+
+	par := New(lexer.New(input))
+	par.ParseProgram()
+
+	errors := par.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors but got none")
+	}
+
+	diag := errors[0]
+	if diag.Code != diagnostic.UnexpectedToken {
+		t.Errorf("wrong error code. expected=%q, got=%q", diagnostic.UnexpectedToken, diag.Code)
+	}
+
+	expected := "2:11: error[E0001]: expected next token to be - =, got - INT instead"
+	if diag.Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, diag.Error())
+	}
+
+	if diag.Span.End.Line != 2 || diag.Span.End.Column != 12 {
+		t.Errorf("wrong span end. expected=2:12, got=%s", diag.Span.End)
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	input := `var int a 5;
+var int b = 10;
+if (b > ) { b; };
+var int c = function() {
+	return 1 + ;
+};
+return b
+var string = "x";
+b;`
+
+	par := New(lexer.New(input))
+	program := par.ParseProgram()
+
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{1, "expected next token to be - =, got - INT instead"},
+		{3, "no prefix parse function for ) found"},
+		{5, "no prefix parse function for ; found"},
+		{8, "expected next token to be - IDENT, got - = instead"},
+	}
+
+	errors := par.Errors()
+	if len(errors) != len(expected) {
+		for _, diag := range errors {
+			t.Errorf("parser error: %q", diag.Error())
 		}
+		t.Fatalf("wrong number of errors. expected=%d, got=%d", len(expected), len(errors))
+	}
 
-		if !isError {
-			t.Errorf("expected a type mismatch error with message '%s', but got: %v", tt.expectedMsg, par.Errors())
+	for i, tt := range expected {
+		if errors[i].Span.Start.Line != tt.line || errors[i].Message != tt.message {
+			t.Errorf("errors[%d] wrong. expected=%d: %q, got=%d: %q", i,
+				tt.line, tt.message, errors[i].Span.Start.Line, errors[i].Message)
 		}
 	}
+
+	statements := []string{}
+	for _, statement := range program.Statements {
+		statements = append(statements, statement.String())
+	}
+
+	expectedStatements := []string{
+		"var int b = 10;",
+		"var int c = function() ;",
+		"return b;",
+		"b",
+	}
+
+	if strings.Join(statements, "\n") != strings.Join(expectedStatements, "\n") {
+		t.Fatalf("wrong statements. expected=%q, got=%q", expectedStatements, statements)
+	}
 }
 
 func checkParserErrors(t *testing.T, par *Parser) {
@@ -114,8 +239,8 @@ func checkParserErrors(t *testing.T, par *Parser) {
 	}
 
 	t.Errorf("parser has %d errors", len(errors))
-	for _, message := range errors {
-		t.Errorf("parser error: %q", message)
+	for _, diag := range errors {
+		t.Errorf("parser error: %q", diag.Error())
 	}
 	t.FailNow()
 }
@@ -217,6 +342,7 @@ func TestParsingPrefixExpression(t *testing.T) {
 	}{
 		{"!4;", "!", 4},
 		{"-9;", "-", 9},
+		{"~5;", "~", 5},
 	}
 
 	for _, tt := range prefixTests {
@@ -265,6 +391,15 @@ func TestParsingInfixExpression(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 ** 5;", 5, "**", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"5 & 5;", 5, "&", 5},
+		{"5 | 5;", 5, "|", 5},
+		{"5 ^ 5;", 5, "^", 5},
+		{"5 << 5;", 5, "<<", 5},
+		{"5 >> 5;", 5, ">>", 5},
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 	}
@@ -429,26 +564,114 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"!(true == true)",
 			"(!(true == true))",
 		},
-		// {
-		// 	"a + add(b * c) + d",
-		// 	"((a + add((b * c))) + d)",
-		// },
-		// {
-		// 	"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
-		// 	"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
-		// },
-		// {
-		// 	"add(a + b + c * d / f + g)",
-		// 	"add((((a + b) + ((c * d) / f)) + g))",
-		// },
-		// {
-		// 	"a * [1, 2, 3, 4][b * c] * d",
-		// 	"((a * ([1, 2, 3, 4][(b * c)])) * d)",
-		// },
-		// {
-		// 	"add(a * b[2], b[1], 2 * [1, 2][1])",
-		// 	"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
-		// },
+		{
+			"a + add(b * c) + d",
+			"((a + add((b * c))) + d)",
+		},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{
+			"add(a + b + c * d / f + g)",
+			"add((((a + b) + ((c * d) / f)) + g))",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a == b && c < d || !e",
+			"(((a == b) && (c < d)) || (!e))",
+		},
+		{
+			"a || b || c",
+			"((a || b) || c)",
+		},
+		{
+			"a * b % c",
+			"((a * b) % c)",
+		},
+		{
+			"a ** b ** c",
+			"(a ** (b ** c))",
+		},
+		{
+			"-a ** b",
+			"(-(a ** b))",
+		},
+		{
+			"a ** -b",
+			"(a ** (-b))",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b == c",
+			"(a & (b == c))",
+		},
+		{
+			"a << b + c",
+			"(a << (b + c))",
+		},
+		{
+			"a < b << c",
+			"(a < (b << c))",
+		},
+		{
+			"a && b | c || d",
+			"((a && (b | c)) || d)",
+		},
+		{
+			"~a & -b",
+			"((~a) & (-b))",
+		},
+		{
+			"a = b = c",
+			"(a = (b = c))",
+		},
+		{
+			"a += b * c || d",
+			"(a += ((b * c) || d))",
+		},
+		{
+			"a[i] = b",
+			"((a[i]) = b)",
+		},
+		{
+			"-a++",
+			"(-(a++))",
+		},
+		{
+			"++a[0] * 2",
+			"((++(a[0])) * 2)",
+		},
+		{
+			"a-- - --b",
+			"((a--) - (--b))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -632,3 +855,833 @@ func TestFunctionParameterParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+
+	lex := lexer.New(input)
+	par := New(lex)
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. Got %d\n",
+			1, len(program.Statements))
+	}
+
+	statement, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. Got %T",
+			program.Statements[0])
+	}
+
+	expression, ok := statement.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("statement.Expression is not ast.CallExpression. Got %T",
+			statement.Expression)
+	}
+
+	if !testIdentifier(t, expression.Function, "add") {
+		return
+	}
+
+	if len(expression.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. Got %d", len(expression.Arguments))
+	}
+
+	testLiteralExpression(t, expression.Arguments[0], 1)
+	testInfixExpression(t, expression.Arguments[1], 2, "*", 3)
+	testInfixExpression(t, expression.Arguments[2], 4, "+", 5)
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	lex := lexer.New(input)
+	par := New(lex)
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := statement.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("expression not ast.ArrayLiteral. Got %T", statement.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. Got %d", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	lex := lexer.New(input)
+	par := New(lex)
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+	indexExpression, ok := statement.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("expression not *ast.IndexExpression. Got %T", statement.Expression)
+	}
+
+	if !testIdentifier(t, indexExpression.Left, "myArray") {
+		return
+	}
+
+	if !testInfixExpression(t, indexExpression.Index, 1, "+", 1) {
+		return
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]int64
+	}{
+		{`{"one": 1, "two": 2, "three": 3}`, map[string]int64{"one": 1, "two": 2, "three": 3}},
+		{`{}`, map[string]int64{}},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		par := New(lex)
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		hash, ok := statement.Expression.(*ast.HashLiteral)
+		if !ok {
+			t.Fatalf("expression is not ast.HashLiteral. Got %T", statement.Expression)
+		}
+
+		if len(hash.Pairs) != len(tt.expected) {
+			t.Errorf("hash.Pairs has wrong length. Got %d", len(hash.Pairs))
+		}
+
+		for key, value := range hash.Pairs {
+			literal, ok := key.(*ast.StringLiteral)
+			if !ok {
+				t.Errorf("key is not ast.StringLiteral. Got %T", key)
+				continue
+			}
+
+			testIntegerLiteral(t, value, tt.expected[literal.Value])
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// answer
+var int a = 42; /* the
+answer */ a;`
+
+	lex := lexer.New(input)
+	lex.SetMode(lexer.ScanComments)
+	par := New(lex)
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	if program.String() != "var int a = 42;a" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+
+	if len(program.Comments) != 2 {
+		t.Fatalf("program.Comments does not contain 2 comments. got=%d", len(program.Comments))
+	}
+
+	if program.Comments[0].String() != "// answer" {
+		t.Errorf("first comment wrong. got=%q", program.Comments[0].String())
+	}
+
+	if program.Comments[1].Pos().Line != 2 || program.Comments[1].Pos().Column != 17 {
+		t.Errorf("second comment position wrong. got=%s", program.Comments[1].Pos())
+	}
+}
+
+func TestUnterminatedCommentError(t *testing.T) {
+	input := "var int a = 1; var int b = 2 /* unterminated"
+
+	par := New(lexer.New(input))
+	par.ParseProgram()
+
+	errors := par.Errors()
+	if len(errors) != 1 {
+		for _, diag := range errors {
+			t.Errorf("parser error: %q", diag.Error())
+		}
+		t.Fatalf("expected exactly 1 error, got=%d", len(errors))
+	}
+
+	if errors[0].Code != diagnostic.UnterminatedComment {
+		t.Errorf("wrong error code. got=%q", errors[0].Code)
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello\tworld\u0021";`
+
+	lex := lexer.New(input)
+	par := New(lex)
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := statement.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("expression not *ast.StringLiteral. got=%T", statement.Expression)
+	}
+
+	if literal.Value != "hello\tworld!" {
+		t.Errorf("literal.Value not %q. got=%q", "hello\tworld!", literal.Value)
+	}
+}
+
+func TestInterpolatedStringParsing(t *testing.T) {
+	input := `"hello ${name}, you are ${age + 1}"`
+
+	lex := lexer.New(input)
+	par := New(lex)
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	statement := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := statement.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("expression not *ast.InterpolatedString. got=%T", statement.Expression)
+	}
+
+	expectedStrings := []string{"hello ", ", you are ", ""}
+	if strings.Join(str.Strings, "|") != strings.Join(expectedStrings, "|") {
+		t.Errorf("str.Strings wrong. expected=%q, got=%q", expectedStrings, str.Strings)
+	}
+
+	if len(str.Expressions) != 2 {
+		t.Fatalf("str.Expressions does not contain 2 expressions. got=%d", len(str.Expressions))
+	}
+
+	testIdentifier(t, str.Expressions[0], "name")
+	testInfixExpression(t, str.Expressions[1], "age", "+", 1)
+
+	if str.String() != `"hello ${name}, you are ${(age + 1)}"` {
+		t.Errorf("str.String() wrong. got=%q", str.String())
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1e3;", 1000},
+		{"2.5e-1;", 0.25},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		par := New(lex)
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := statement.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("expression not *ast.FloatLiteral. got=%T", statement.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+
+	par := New(lexer.New("1e400;"))
+	par.ParseProgram()
+	if len(par.Errors()) != 1 || par.Errors()[0].Code != diagnostic.InvalidFloat {
+		t.Errorf("expected an invalid float error, got=%v", par.Errors())
+	}
+}
+
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF;", 255},
+		{"0o755;", 493},
+		{"0b1010;", 10},
+		{"1_000_000;", 1000000},
+		{"9223372036854775807;", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		par := New(lex)
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := statement.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("expression not *ast.IntegerLiteral. got=%T", statement.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %d. got=%d", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestBigIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775808;", "9223372036854775808"},
+		{"0x1_0000_0000_0000_0000;", "18446744073709551616"},
+		{"0b1" + strings.Repeat("0", 64) + ";", "18446744073709551616"},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := statement.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("expression not *ast.IntegerLiteral. got=%T", statement.Expression)
+		}
+
+		if literal.Big == nil || literal.Big.String() != tt.expected {
+			t.Errorf("literal.Big not %s. got=%v", tt.expected, literal.Big)
+		}
+	}
+}
+
+func TestIntegerLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0b2;", `invalid digit '2' in binary literal "0b2"`},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		par.ParseProgram()
+
+		errors := par.Errors()
+		if len(errors) != 1 || errors[0].Message != tt.expected {
+			t.Errorf("expected error %q, got=%v", tt.expected, errors)
+		}
+	}
+}
+
+func TestLogicalExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input      string
+		leftValue  interface{}
+		operator   string
+		rightValue interface{}
+	}{
+		{"a && b", "a", "&&", "b"},
+		{"true || false", true, "||", false},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		par := New(lex)
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		expression, ok := statement.Expression.(*ast.LogicalExpression)
+		if !ok {
+			t.Fatalf("expression is not ast.LogicalExpression. Got %T", statement.Expression)
+		}
+
+		if !testLiteralExpression(t, expression.Left, tt.leftValue) {
+			return
+		}
+
+		if expression.Operator != tt.operator {
+			t.Errorf("expression.Operator is not %q. Got %q", tt.operator, expression.Operator)
+		}
+
+		if !testLiteralExpression(t, expression.Right, tt.rightValue) {
+			return
+		}
+	}
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		target   string
+		operator string
+		value    interface{}
+	}{
+		{"x = 5;", "x", "=", 5},
+		{"x += y;", "x", "+=", "y"},
+		{"x -= 1;", "x", "-=", 1},
+		{"x *= 2;", "x", "*=", 2},
+		{"x /= true;", "x", "/=", true},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		expression, ok := statement.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("expression is not ast.AssignExpression. Got %T", statement.Expression)
+		}
+
+		if !testIdentifier(t, expression.Target, tt.target) {
+			return
+		}
+
+		if expression.Operator != tt.operator {
+			t.Errorf("expression.Operator is not %q. Got %q", tt.operator, expression.Operator)
+		}
+
+		if !testLiteralExpression(t, expression.Value, tt.value) {
+			return
+		}
+	}
+}
+
+func TestUpdateExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+		prefix   bool
+	}{
+		{"++i;", "++", true},
+		{"--i;", "--", true},
+		{"i++;", "++", false},
+		{"i--;", "--", false},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		expression, ok := statement.Expression.(*ast.UpdateExpression)
+		if !ok {
+			t.Fatalf("expression is not ast.UpdateExpression. Got %T", statement.Expression)
+		}
+
+		if expression.Operator != tt.operator || expression.Prefix != tt.prefix {
+			t.Errorf("wrong update expression for %q. Got operator %q, prefix %t",
+				tt.input, expression.Operator, expression.Prefix)
+		}
+
+		if !testIdentifier(t, expression.Target, "i") {
+			return
+		}
+	}
+}
+
+func TestInvalidAssignTargets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 = x;", "1:3: error[E0006]: invalid assignment target: 5"},
+		{"a + b = c;", "1:7: error[E0006]: invalid assignment target: (a + b)"},
+		{"f() += 1;", "1:5: error[E0006]: invalid assignment target: f()"},
+		{"++5;", "1:1: error[E0006]: invalid assignment target: 5"},
+		{"true--;", "1:5: error[E0006]: invalid assignment target: true"},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		par.ParseProgram()
+
+		errors := par.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d", tt.input, len(errors))
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestLoopStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 10) { x++; }", "while ((x < 10)) (x++)"},
+		{"while (true) { break; };", "while (true) break;"},
+		{"for (var int i = 0; i < 3; i++) { continue; }",
+			"for (var int i = 0; (i < 3); (i++)) continue;"},
+		{"for (i = 0; i < 3; i += 1) { x }", "for ((i = 0); (i < 3); (i += 1)) x"},
+		{"for (;;) { break; }", "for (; ; ) break;"},
+		{"for (; x;) { }", "for (; x; ) "},
+		{"while (a) { while (b) { break; } continue; }", "while (a) while (b) break;continue;"},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement for %q. Got %d",
+				tt.input, len(program.Statements))
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestForStatementParts(t *testing.T) {
+	par := New(lexer.New("for (var int i = 0; i < 10; i++) { i; }"))
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	statement, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForStatement. Got %T", program.Statements[0])
+	}
+
+	if _, ok := statement.Init.(*ast.VarStatement); !ok {
+		t.Errorf("statement.Init is not ast.VarStatement. Got %T", statement.Init)
+	}
+
+	if !testInfixExpression(t, statement.Condition, "i", "<", 10) {
+		return
+	}
+
+	if _, ok := statement.Post.(*ast.UpdateExpression); !ok {
+		t.Errorf("statement.Post is not ast.UpdateExpression. Got %T", statement.Post)
+	}
+
+	if len(statement.Body.Statements) != 1 {
+		t.Errorf("body does not contain 1 statement. Got %d", len(statement.Body.Statements))
+	}
+}
+
+func TestBranchOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"break;", []string{"1:1: error[E0007]: break outside of a loop"}},
+		{"if (true) { continue; }", []string{"1:13: error[E0007]: continue outside of a loop"}},
+		{"while (true) { function() { break; }; }",
+			[]string{"1:29: error[E0007]: break outside of a loop"}},
+		{"break; var int x = 1; continue;", []string{
+			"1:1: error[E0007]: break outside of a loop",
+			"1:23: error[E0007]: continue outside of a loop",
+		}},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		par.ParseProgram()
+
+		errors := par.Errors()
+		if len(errors) != len(tt.expected) {
+			t.Errorf("expected %d errors for %q, got %d", len(tt.expected), tt.input, len(errors))
+			continue
+		}
+
+		for i, expected := range tt.expected {
+			if errors[i].Error() != expected {
+				t.Errorf("wrong error. expected=%q, got=%q", expected, errors[i].Error())
+			}
+		}
+	}
+}
+
+func TestForInStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		key      string
+		value    string
+		expected string
+	}{
+		{"for (x in items) { x; }", "", "x", "for (x in items) x"},
+		{"for (k, v in {1: 2}) { k; }", "k", "v", "for (k, v in {1:2}) k"},
+		{"for (ch in \"abc\") { }", "", "ch", "for (ch in abc) "},
+		{"for (i in range(1, 10)) { break; }", "", "i", "for (i in range(1, 10)) break;"},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement, ok := program.Statements[0].(*ast.ForInStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForInStatement. Got %T", program.Statements[0])
+		}
+
+		if tt.key == "" && statement.Key != nil {
+			t.Errorf("statement.Key is not nil. Got %s", statement.Key)
+		}
+		if tt.key != "" && !testIdentifier(t, statement.Key, tt.key) {
+			return
+		}
+		if !testIdentifier(t, statement.Value, tt.value) {
+			return
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestFunctionStatement(t *testing.T) {
+	input := `function add(a, b) { return a + b; }
+function noop() { };
+function(x) { x }(1);`
+
+	par := New(lexer.New(input))
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. Got %d", len(program.Statements))
+	}
+
+	tests := []struct {
+		name       string
+		parameters []string
+		expected   string
+	}{
+		{"add", []string{"a", "b"}, "function add(a, b) return (a + b);"},
+		{"noop", []string{}, "function noop() "},
+	}
+
+	for i, tt := range tests {
+		statement, ok := program.Statements[i].(*ast.FunctionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.FunctionStatement. Got %T", i, program.Statements[i])
+		}
+
+		if !testIdentifier(t, statement.Name, tt.name) {
+			return
+		}
+
+		if statement.Function.Name != tt.name {
+			t.Errorf("function literal has wrong name. expected=%q, got=%q", tt.name, statement.Function.Name)
+		}
+
+		if len(statement.Function.Parameters) != len(tt.parameters) {
+			t.Fatalf("wrong number of parameters. expected=%d, got=%d",
+				len(tt.parameters), len(statement.Function.Parameters))
+		}
+		for j, parameter := range tt.parameters {
+			testIdentifier(t, statement.Function.Parameters[j], parameter)
+		}
+
+		if statement.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, statement.String())
+		}
+	}
+
+	if _, ok := program.Statements[2].(*ast.ExpressionStatement); !ok {
+		t.Errorf("anonymous function is not an ast.ExpressionStatement. Got %T", program.Statements[2])
+	}
+}
+
+func TestTypedFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input          string
+		parameters     []string
+		parameterTypes []string
+		returnType     string
+		expected       string
+	}{
+		{"function(int a, string b): int { a }", []string{"a", "b"}, []string{"int", "string"}, "int",
+			"function(int a, string b): int a"},
+		{"function(float x, y) { x }", []string{"x", "y"}, []string{"float", ""}, "",
+			"function(float x, y) x"},
+		{"function(): string { \"s\" }", []string{}, []string{}, "string",
+			"function(): string s"},
+		{"function f(a) : float { a }", []string{"a"}, []string{""}, "float",
+			"function f(a): float a"},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		var function *ast.FunctionLiteral
+		switch statement := program.Statements[0].(type) {
+		case *ast.ExpressionStatement:
+			function = statement.Expression.(*ast.FunctionLiteral)
+		case *ast.FunctionStatement:
+			function = statement.Function
+		}
+
+		if len(function.Parameters) != len(tt.parameters) {
+			t.Fatalf("wrong number of parameters. expected=%d, got=%d",
+				len(tt.parameters), len(function.Parameters))
+		}
+
+		for i, name := range tt.parameters {
+			testIdentifier(t, function.Parameters[i], name)
+			if typeString(function.ParameterTypes[i]) != tt.parameterTypes[i] {
+				t.Errorf("parameter %s has wrong type. expected=%q, got=%q",
+					name, tt.parameterTypes[i], typeString(function.ParameterTypes[i]))
+			}
+		}
+
+		if typeString(function.ReturnType) != tt.returnType {
+			t.Errorf("wrong return type. expected=%q, got=%q", tt.returnType, typeString(function.ReturnType))
+		}
+
+		if function.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, function.String())
+		}
+	}
+}
+
+// typeString renders an optional annotation, "" standing for none.
+func typeString(typeExpression ast.TypeExpression) string {
+	if typeExpression == nil {
+		return ""
+	}
+	return typeExpression.String()
+}
+
+func TestInvalidFunctionSignatures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"function(int) { }", "1:13: error[E0001]: expected next token to be - IDENT, got - ) instead"},
+		{"function(a, 1) { }", "1:13: error[E0001]: expected next token to be - IDENT, got - INT instead"},
+		{"function(a): 5 { }", "1:14: error[E0001]: expected next token to be - type, got - INT instead"},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		par.ParseProgram()
+
+		errors := par.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d", tt.input, len(errors))
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0].Error())
+		}
+	}
+}
+
+func TestTypeExpressions(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType string
+		expectedNode interface{}
+	}{
+		{"var bool b = true;", "bool", &ast.NamedType{}},
+		{"var [int] xs = [1];", "[int]", &ast.ArrayType{}},
+		{"var [[string]] m = [];", "[[string]]", &ast.ArrayType{}},
+		{"var {string: int} h = {};", "{string: int}", &ast.HashType{}},
+		{"var {int: [float?]} h = {};", "{int: [float?]}", &ast.HashType{}},
+		{"var function(int): string f = g;", "function(int): string", &ast.FunctionType{}},
+		{"var function() f = g;", "function()", &ast.FunctionType{}},
+		{"var function(int, [int]): bool f = g;", "function(int, [int]): bool", &ast.FunctionType{}},
+		{"var int? n = null;", "int?", &ast.NullableType{}},
+		{"var int?? n = null;", "int?", &ast.NullableType{}},
+		{"var int | string v = 1;", "int | string", &ast.UnionType{}},
+		{"var int | (string | bool) v = 1;", "int | string | bool", &ast.UnionType{}},
+		{"var (int | string)? v = 1;", "(int | string)?", &ast.NullableType{}},
+		{"var function(): int | string f = g;", "function(): int | string", &ast.FunctionType{}},
+		{"var (function(): int) | string f = g;", "(function(): int) | string", &ast.UnionType{}},
+		{"var (function(): int)? f = g;", "(function(): int)?", &ast.NullableType{}},
+		{"var (int) n = 1;", "int", &ast.NamedType{}},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		program := par.ParseProgram()
+		checkParserErrors(t, par)
+
+		statement := program.Statements[0].(*ast.VarStatement)
+		if statement.Type.String() != tt.expectedType {
+			t.Errorf("wrong type for %q. expected=%q, got=%q", tt.input, tt.expectedType, statement.Type.String())
+		}
+
+		if fmt.Sprintf("%T", statement.Type) != fmt.Sprintf("%T", tt.expectedNode) {
+			t.Errorf("wrong type node for %q. expected=%T, got=%T", tt.input, tt.expectedNode, statement.Type)
+		}
+	}
+}
+
+func TestCompoundTypeAnnotationsInSignatures(t *testing.T) {
+	input := "function(function(int): int f, [string]? names, {string: bool} flags): [int] { }"
+
+	par := New(lexer.New(input))
+	program := par.ParseProgram()
+	checkParserErrors(t, par)
+
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+
+	expected := []string{"function(int): int", "[string]?", "{string: bool}"}
+	for i, parameterType := range expected {
+		if typeString(function.ParameterTypes[i]) != parameterType {
+			t.Errorf("parameter %d has wrong type. expected=%q, got=%q",
+				i, parameterType, typeString(function.ParameterTypes[i]))
+		}
+	}
+
+	if typeString(function.ReturnType) != "[int]" {
+		t.Errorf("wrong return type. expected=%q, got=%q", "[int]", typeString(function.ReturnType))
+	}
+}
+
+func TestInvalidTypeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var 5 x = 1;", "1:5: error[E0001]: expected next token to be - IDENT or type, got - INT instead"},
+		{"var = 1;", "1:5: error[E0001]: expected next token to be - IDENT or type, got - = instead"},
+		{"var [int x = 1;", "1:10: error[E0001]: expected next token to be - ], got - IDENT instead"},
+		{"var [int: string] x = 1;", "1:9: error[E0001]: expected next token to be - ], got - : instead"},
+		{"var int | x = 1;", "1:11: error[E0001]: expected next token to be - type, got - IDENT instead"},
+		{"var function(int,) f = g;", "1:18: error[E0001]: expected next token to be - type, got - ) instead"},
+	}
+
+	for _, tt := range tests {
+		par := New(lexer.New(tt.input))
+		par.ParseProgram()
+
+		errors := par.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d", tt.input, len(errors))
+			continue
+		}
+
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0].Error())
+		}
+	}
+}
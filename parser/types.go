@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"Go-Tutorials/Core-lang/ast"
+	"Go-Tutorials/Core-lang/diagnostic"
+	"Go-Tutorials/Core-lang/token"
+)
+
+// The type grammar, from loosest to tightest binding:
+//
+//	type     = nullable { "|" nullable }
+//	nullable = primary { "?" }
+//	primary  = "int" | "float" | "string" | "bool"
+//	         | "[" type "]"
+//	         | "{" type ":" type "}"
+//	         | "function" "(" [ type { "," type } ] ")" [ ":" type ]
+//	         | "(" type ")"
+//
+// A function type's return type extends as far as possible, so
+// function(): int | string returns a union; use parentheses for a union
+// that contains a function type.
+
+func isTypeStart(tokenType token.TokenType) bool {
+	switch tokenType {
+	case token.INT_TYPE, token.FLOAT_TYPE, token.STRING_TYPE, token.BOOL_TYPE,
+		token.LEFT_BRACKET, token.LEFT_CURLY_BRACE, token.FUNCTION, token.LEFT_PARANTHESIS:
+		return true
+	default:
+		return false
+	}
+}
+
+// expectNextType parses the type that starts at the peek token, reporting
+// an error and returning nil if there is none.
+func (par *Parser) expectNextType() ast.TypeExpression {
+	if !isTypeStart(par.peekToken.Type) {
+		par.errorAt(diagnostic.UnexpectedToken, diagnostic.TokenSpan(par.peekToken),
+			"expected next token to be - type, got - %s instead", par.peekToken.Type)
+		return nil
+	}
+
+	par.nextToken()
+	return par.parseType()
+}
+
+func (par *Parser) parseType() ast.TypeExpression {
+	first := par.parseNullableType()
+	if first == nil || !par.peekedTokenIs(token.BIT_OR) {
+		return first
+	}
+
+	union := &ast.UnionType{Token: par.peekToken, Types: []ast.TypeExpression{first}}
+	par.nextToken()
+	rest := par.expectNextType()
+	if rest == nil {
+		return nil
+	}
+
+	// the rest is parsed as a union of its own; a | (b | c) is a | b | c
+	if nested, ok := rest.(*ast.UnionType); ok {
+		union.Types = append(union.Types, nested.Types...)
+	} else {
+		union.Types = append(union.Types, rest)
+	}
+
+	return union
+}
+
+func (par *Parser) parseNullableType() ast.TypeExpression {
+	result := par.parsePrimaryType()
+	if result == nil {
+		return nil
+	}
+
+	for par.peekedTokenIs(token.QUESTION) {
+		par.nextToken()
+		// int?? admits nothing more than int?
+		if _, ok := result.(*ast.NullableType); !ok {
+			result = &ast.NullableType{Token: par.currentToken, Type: result}
+		}
+	}
+
+	return result
+}
+
+func (par *Parser) parsePrimaryType() ast.TypeExpression {
+	switch par.currentToken.Type {
+	case token.INT_TYPE, token.FLOAT_TYPE, token.STRING_TYPE, token.BOOL_TYPE:
+		return &ast.NamedType{Token: par.currentToken, Name: par.currentToken.Literal}
+
+	case token.LEFT_BRACKET:
+		arrayType := &ast.ArrayType{Token: par.currentToken}
+		if arrayType.Element = par.expectNextType(); arrayType.Element == nil {
+			return nil
+		}
+		if !par.ensureNext(token.RIGHT_BRACKET) {
+			return nil
+		}
+		return arrayType
+
+	case token.LEFT_CURLY_BRACE:
+		hashType := &ast.HashType{Token: par.currentToken}
+		if hashType.Key = par.expectNextType(); hashType.Key == nil {
+			return nil
+		}
+		if !par.ensureNext(token.COLON) {
+			return nil
+		}
+		if hashType.Value = par.expectNextType(); hashType.Value == nil {
+			return nil
+		}
+		if !par.ensureNext(token.RIGHT_CURLY_BRACE) {
+			return nil
+		}
+		return hashType
+
+	case token.FUNCTION:
+		return par.parseFunctionType()
+
+	case token.LEFT_PARANTHESIS:
+		inner := par.expectNextType()
+		if inner == nil || !par.ensureNext(token.RIGHT_PARANTHESIS) {
+			return nil
+		}
+		return inner
+
+	default:
+		par.errorAt(diagnostic.UnexpectedToken, diagnostic.TokenSpan(par.currentToken),
+			"expected type, got - %s instead", par.currentToken.Type)
+		return nil
+	}
+}
+
+func (par *Parser) parseFunctionType() ast.TypeExpression {
+	functionType := &ast.FunctionType{Token: par.currentToken, Parameters: []ast.TypeExpression{}}
+
+	if !par.ensureNext(token.LEFT_PARANTHESIS) {
+		return nil
+	}
+
+	if !par.peekedTokenIs(token.RIGHT_PARANTHESIS) {
+		for {
+			parameter := par.expectNextType()
+			if parameter == nil {
+				return nil
+			}
+			functionType.Parameters = append(functionType.Parameters, parameter)
+
+			if !par.peekedTokenIs(token.COMMA) {
+				break
+			}
+			par.nextToken()
+		}
+	}
+
+	if !par.ensureNext(token.RIGHT_PARANTHESIS) {
+		return nil
+	}
+
+	if par.peekedTokenIs(token.COLON) {
+		par.nextToken()
+		if functionType.Return = par.expectNextType(); functionType.Return == nil {
+			return nil
+		}
+	}
+
+	return functionType
+}
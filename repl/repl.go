@@ -1,10 +1,12 @@
 package repl
 
 import (
+	"Go-Tutorials/Core-lang/diagnostic"
 	"Go-Tutorials/Core-lang/evaluator"
 	"Go-Tutorials/Core-lang/lexer"
 	"Go-Tutorials/Core-lang/object"
 	"Go-Tutorials/Core-lang/parser"
+	"Go-Tutorials/Core-lang/types"
 	"bufio"
 	"fmt"
 	"io"
@@ -24,6 +26,7 @@ const PROMPT = "::> "
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 	environment := object.NewEnvironment()
+	checker := types.NewChecker()
 
 	for {
 		fmt.Fprintf(out, PROMPT)
@@ -38,7 +41,12 @@ func Start(in io.Reader, out io.Writer) {
 
 		program := par.ParseProgram()
 		if len(par.Errors()) != 0 {
-			printParseErrors(out, par.Errors())
+			printErrors(out, line, "parser", par.Errors())
+			continue
+		}
+
+		if errors := checker.Check(program); len(errors) != 0 {
+			printErrors(out, line, "type", errors)
 			continue
 		}
 
@@ -50,11 +58,9 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 
-func printParseErrors(out io.Writer, errors []string) {
+func printErrors(out io.Writer, source, kind string, errors []*diagnostic.Diagnostic) {
 	io.WriteString(out, CORE_LANG)
 	io.WriteString(out, "Opps! We ran in to some issue \n")
-	io.WriteString(out, " parser errors:\n")
-	for _, message := range errors {
-		io.WriteString(out, "\t"+message+"\n")
-	}
+	io.WriteString(out, " "+kind+" errors:\n")
+	io.WriteString(out, diagnostic.RenderAll(source, errors))
 }
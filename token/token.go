@@ -1,15 +1,47 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first character of the token
+	End     Position // position immediately after the last character
+}
+
+// Position is a location in the source. Line and Column are 1-based,
+// Offset is the 0-based byte offset into the input.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// IsValid reports whether the position has been set by the lexer.
+func (pos Position) IsValid() bool { return pos.Line > 0 }
+
+// String formats the position as "file:line:column", omitting the
+// filename when there is none.
+func (pos Position) String() string {
+	if !pos.IsValid() {
+		if pos.Filename != "" {
+			return pos.Filename
+		}
+		return "-"
+	}
+	if pos.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column)
+	}
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
 }
 
 const (
 	INVALID = "INVALID"
 	END     = "END"
+	COMMENT = "COMMENT" // only returned when the lexer runs in ScanComments mode
 
 	EQ     = "=="
 	NOT_EQ = "!="
@@ -17,22 +49,51 @@ const (
 	// Identifiers and literals
 	IDENT  = "IDENT" // variable names,
 	INT    = "INT"   // integer numbers
+	FLOAT  = "FLOAT" // floating-point numbers
 	STRING = "STRING"
 
+	// Pieces of an interpolated string such as "a ${x} b ${y} c": the text
+	// before the first "${", between embedded expressions and after the last.
+	STRING_HEAD   = "STRING_HEAD"
+	STRING_MIDDLE = "STRING_MIDDLE"
+	STRING_TAIL   = "STRING_TAIL"
+
 	// Operators
-	ASSIGN_OP = "="
-	PLUS      = "+"
-	MINUS     = "-"
-	BANG      = "!"
-	ASTERISK  = "*"
-	SLASH     = "/"
+	ASSIGN_OP       = "="
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	INCREMENT       = "++"
+	DECREMENT       = "--"
+	PLUS            = "+"
+	MINUS           = "-"
+	BANG            = "!"
+	ASTERISK        = "*"
+	SLASH           = "/"
+	PERCENT         = "%"
+	POWER           = "**"
+
+	LESS_THEN     = "<"
+	GREATER_THEN  = ">"
+	LESS_EQUAL    = "<="
+	GREATER_EQUAL = ">="
+
+	AND = "&&"
+	OR  = "||"
 
-	LESS_THEN    = "<"
-	GREATER_THEN = ">"
+	BIT_AND     = "&"
+	BIT_OR      = "|"
+	BIT_XOR     = "^"
+	BIT_NOT     = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
 
 	// Delimeters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	QUESTION  = "?"
 
 	LEFT_PARANTHESIS  = "("
 	RIGHT_PARANTHESIS = ")"
@@ -49,20 +110,36 @@ const (
 	RETURN      = "RETURN"
 	IF          = "IF"
 	ELSE        = "ELSE"
+	WHILE       = "WHILE"
+	FOR         = "FOR"
+	BREAK       = "BREAK"
+	CONTINUE    = "CONTINUE"
+	IN          = "IN"
 	INT_TYPE    = "INT_TYPE"
+	FLOAT_TYPE  = "FLOAT_TYPE"
 	STRING_TYPE = "STRING_TYPE"
+	BOOL_TYPE   = "BOOL_TYPE"
+	NULL        = "NULL"
 )
 
 var tokenDictionary = map[string]TokenType{
 	"function": FUNCTION,
 	"var":      VAR,
 	"int":      INT_TYPE,
+	"float":    FLOAT_TYPE,
 	"string":   STRING_TYPE,
+	"bool":     BOOL_TYPE,
+	"null":     NULL,
 	"true":     TRUE,
 	"false":    FALSE,
 	"if":       IF,
 	"else":     ELSE,
 	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"in":       IN,
 }
 
 func LookupIdentifier(identifier string) TokenType {
@@ -0,0 +1,843 @@
+package types
+
+import (
+	"Go-Tutorials/Core-lang/ast"
+	"Go-Tutorials/Core-lang/diagnostic"
+	"Go-Tutorials/Core-lang/token"
+	"sort"
+)
+
+// builtins holds the signatures of the evaluator's built-in functions.
+var builtins = map[string]Type{
+	"len":    &Function{Parameters: []Type{Unknown}, Return: Int},
+	"int":    &Function{Parameters: []Type{Unknown}, Return: Int},
+	"float":  &Function{Parameters: []Type{Unknown}, Return: Float},
+	"string": &Function{Parameters: []Type{Unknown}, Return: String},
+	"range":  &Function{Return: Range, Variadic: true},
+}
+
+type binding struct {
+	typ      Type
+	declared bool // the type was written in the source, not inferred
+	function bool // bound by a function declaration
+}
+
+type scope struct {
+	bindings map[string]*binding
+	outer    *scope
+	function bool // the scope of a function body
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{bindings: make(map[string]*binding), outer: outer}
+}
+
+func (s *scope) lookup(name string) (*binding, bool) {
+	for ; s != nil; s = s.outer {
+		if b, ok := s.bindings[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// copy returns a copy of s that later changes to s do not affect.
+func (s *scope) copy() *scope {
+	copied := &scope{bindings: make(map[string]*binding, len(s.bindings)), outer: s.outer, function: s.function}
+	for name, b := range s.bindings {
+		saved := *b
+		copied.bindings[name] = &saved
+	}
+	return copied
+}
+
+// lookupFrom is lookup that also reports whether the binding belongs to a
+// function enclosing the one s is part of.
+func (s *scope) lookupFrom(name string) (b *binding, outer bool, ok bool) {
+	for ; s != nil; s = s.outer {
+		if b, ok := s.bindings[name]; ok {
+			return b, outer, true
+		}
+		outer = outer || s.function
+	}
+	return nil, false, false
+}
+
+// function is the state of a function literal whose body is being checked.
+type function struct {
+	declared Type   // declared return type, nil if there is none
+	returns  []Type // types of the return statements seen so far
+}
+
+// Checker infers the types of expressions and reports the places where a
+// value cannot have the type its context requires. Values the checker cannot
+// type, such as untyped parameters, have type Unknown and are never reported:
+// the evaluator still checks them at runtime.
+type Checker struct {
+	scope     *scope
+	functions []*function
+	errors    []*diagnostic.Diagnostic
+
+	// narrowed holds the non-null types of nullable variables inside the
+	// branch of an if that compared them with null
+	narrowed map[*binding]narrowing
+}
+
+type narrowing struct {
+	typ   Type
+	depth int // the function nesting the narrowing applies to
+}
+
+func NewChecker() *Checker {
+	return &Checker{scope: newScope(nil), narrowed: make(map[*binding]narrowing)}
+}
+
+// Check type-checks program and returns the errors found in it. Variables
+// and functions declared by program stay visible to later calls, so that a
+// REPL can check its input line by line. A program with errors is not run,
+// so then the bindings are restored to what they were before.
+func (c *Checker) Check(program *ast.Program) []*diagnostic.Diagnostic {
+	saved := c.scope.copy()
+
+	c.errors = nil
+	c.checkStatements(program.Statements)
+	if len(c.errors) != 0 {
+		c.scope = saved
+	}
+	return c.errors
+}
+
+// Check type-checks a complete program.
+func Check(program *ast.Program) []*diagnostic.Diagnostic {
+	return NewChecker().Check(program)
+}
+
+func (c *Checker) errorAt(code string, node ast.Node, format string, a ...interface{}) *diagnostic.Diagnostic {
+	diag := diagnostic.New(code, span(node), format, a...)
+	c.errors = append(c.errors, diag)
+	return diag
+}
+
+func (c *Checker) define(name string, t Type, declared bool) {
+	c.scope.bindings[name] = &binding{typ: t, declared: declared}
+}
+
+func (c *Checker) defineFunction(name string, fn *Function) {
+	c.scope.bindings[name] = &binding{typ: fn, function: true}
+}
+
+// identifierType returns the type of the variable name. A variable declared
+// without a type may be reassigned a value of any type, possibly after a
+// function using it has been checked, so such variables of enclosing
+// functions are unknown inside a function body.
+func (c *Checker) identifierType(name string) (Type, bool) {
+	b, outer, ok := c.scope.lookupFrom(name)
+	switch {
+	case !ok:
+		return nil, false
+	case outer && !b.declared && !b.function:
+		return Unknown, true
+	}
+
+	if narrowed, ok := c.narrowed[b]; ok && narrowed.depth == len(c.functions) {
+		return narrowed.typ, true
+	}
+	return b.typ, true
+}
+
+// nullTest recognizes the conditions x != null and x == null, in either
+// order, and returns x and whether the condition holds when x is not null.
+func nullTest(condition ast.Expression) (string, bool) {
+	infix, ok := condition.(*ast.InfixExpression)
+	if !ok || (infix.Operator != "==" && infix.Operator != "!=") {
+		return "", false
+	}
+
+	operand, other := infix.Left, infix.Right
+	if _, ok := operand.(*ast.NullLiteral); ok {
+		operand, other = other, operand
+	}
+	identifier, ok := operand.(*ast.Identifier)
+	if _, isNull := other.(*ast.NullLiteral); !ok || !isNull {
+		return "", false
+	}
+
+	return identifier.Value, infix.Operator == "!="
+}
+
+// checkNarrowed checks a branch of an if. When nonNull is set the branch
+// only runs if the variable name is not null, so a nullable variable has
+// its non-null type there until it is assigned.
+func (c *Checker) checkNarrowed(block *ast.BlockStatement, name string, nonNull bool) Type {
+	t, ok := c.identifierType(name)
+	nullable, isNullable := t.(*Nullable)
+	if !nonNull || !ok || !isNullable {
+		return c.checkBlock(block)
+	}
+
+	b, _ := c.scope.lookup(name)
+	previous, narrowed := c.narrowed[b]
+	c.narrowed[b] = narrowing{typ: nullable.Type, depth: len(c.functions)}
+
+	result := c.checkBlock(block)
+
+	if narrowed {
+		c.narrowed[b] = previous
+	} else {
+		delete(c.narrowed, b)
+	}
+	return result
+}
+
+func (c *Checker) enterScope() {
+	c.scope = newScope(c.scope)
+}
+
+func (c *Checker) leaveScope() {
+	c.scope = c.scope.outer
+}
+
+// checkStatements checks a list of statements and returns the type of the
+// value of the last one, which is the value of a block.
+func (c *Checker) checkStatements(statements []ast.Statement) Type {
+	for _, statement := range statements {
+		if declaration, ok := statement.(*ast.FunctionStatement); ok {
+			c.defineFunction(declaration.Name.Value, signature(declaration.Function))
+		}
+	}
+
+	var result Type = Null
+	for _, statement := range statements {
+		result = c.checkStatement(statement)
+	}
+
+	return result
+}
+
+func (c *Checker) checkStatement(statement ast.Statement) Type {
+	switch statement := statement.(type) {
+	case *ast.ExpressionStatement:
+		return c.infer(statement.Expression)
+
+	case *ast.VarStatement:
+		valueType := c.infer(statement.Value)
+		declaredType := FromAnnotation(statement.Type)
+		if declaredType == nil {
+			statement.InferredType = ToAnnotation(valueType)
+			c.define(statement.Name.Value, valueType, false)
+			return Unknown
+		}
+
+		if !assignable(valueType, declaredType) {
+			c.errorAt(diagnostic.TypeMismatch, statement.Value,
+				"type mismatch: cannot assign %s to %s variable", valueType, declaredType).
+				WithHint("declare the variable as %s or change the assigned value", valueType)
+		}
+		c.define(statement.Name.Value, declaredType, true)
+
+	case *ast.ReturnStatement:
+		var node ast.Node = statement
+		var valueType Type = Null
+		if statement.ReturnValue != nil {
+			node = statement.ReturnValue
+			valueType = c.infer(statement.ReturnValue)
+		}
+		if len(c.functions) > 0 {
+			c.checkReturn(node, valueType)
+		}
+
+	case *ast.FunctionStatement:
+		fn := c.inferFunction(statement.Function)
+		c.defineFunction(statement.Name.Value, fn)
+
+	case *ast.WhileStatement:
+		c.infer(statement.Condition)
+		c.checkBlock(statement.Body)
+
+	case *ast.ForStatement:
+		c.enterScope()
+		if statement.Init != nil {
+			c.checkStatement(statement.Init)
+		}
+		if statement.Condition != nil {
+			c.infer(statement.Condition)
+		}
+		if statement.Post != nil {
+			c.infer(statement.Post)
+		}
+		c.checkBlock(statement.Body)
+		c.leaveScope()
+
+	case *ast.ForInStatement:
+		c.checkForIn(statement)
+	}
+
+	return Unknown
+}
+
+// checkBlock checks the body of an if, while or for statement. Like the
+// evaluator it checks the body in the enclosing scope, so its declarations
+// stay visible after it. As the body might not run, a variable it declares
+// again has an unknown type afterwards.
+func (c *Checker) checkBlock(block *ast.BlockStatement) Type {
+	before := make(map[string]*binding, len(c.scope.bindings))
+	for name, b := range c.scope.bindings {
+		before[name] = b
+	}
+
+	result := c.checkStatements(block.Statements)
+
+	for name, b := range c.scope.bindings {
+		previous, ok := before[name]
+		if !ok {
+			previous, ok = c.scope.outer.lookup(name)
+		}
+		if ok && previous != b && (previous.typ.String() != b.typ.String() || previous.declared != b.declared) {
+			c.scope.bindings[name] = &binding{typ: Unknown}
+		}
+	}
+
+	return result
+}
+
+func (c *Checker) checkForIn(statement *ast.ForInStatement) {
+	iterable := c.infer(statement.Iterable)
+
+	key, value := Unknown, Unknown
+	switch iterable := iterable.(type) {
+	case *Array:
+		key, value = Int, iterable.Element
+	case *Hash:
+		key, value = iterable.Key, iterable.Value
+	default:
+		switch iterable {
+		case String:
+			key, value = Int, String
+		case Range:
+			key, value = Int, Int
+		case Unknown:
+		default:
+			c.errorAt(diagnostic.NotIterable, statement.Iterable, "cannot iterate over %s", iterable)
+		}
+	}
+
+	c.enterScope()
+	defer c.leaveScope()
+
+	if statement.Key != nil {
+		c.define(statement.Key.Value, key, false)
+		c.define(statement.Value.Value, value, false)
+	} else if _, ok := iterable.(*Hash); ok {
+		c.define(statement.Value.Value, key, false)
+	} else {
+		c.define(statement.Value.Value, value, false)
+	}
+
+	c.checkStatements(statement.Body.Statements)
+}
+
+// checkReturn checks a value returned from the innermost function against
+// its declared return type. Without a declared type the return type of the
+// function is inferred from its returned values.
+func (c *Checker) checkReturn(node ast.Node, valueType Type) {
+	fn := c.functions[len(c.functions)-1]
+	if fn.declared == nil {
+		fn.returns = append(fn.returns, valueType)
+		return
+	}
+
+	if !assignable(valueType, fn.declared) {
+		c.errorAt(diagnostic.TypeMismatch, node,
+			"type mismatch: cannot return %s from function returning %s", valueType, fn.declared)
+	}
+}
+
+// signature returns the type of a function literal as far as it is known
+// without checking its body.
+func signature(literal *ast.FunctionLiteral) *Function {
+	fn := &Function{Return: Unknown}
+	for i := range literal.Parameters {
+		var parameterType Type = Unknown
+		if literal.ParameterTypes != nil && literal.ParameterTypes[i] != nil {
+			parameterType = FromAnnotation(literal.ParameterTypes[i])
+		}
+		fn.Parameters = append(fn.Parameters, parameterType)
+	}
+	if literal.ReturnType != nil {
+		fn.Return = FromAnnotation(literal.ReturnType)
+	}
+
+	return fn
+}
+
+func (c *Checker) inferFunction(literal *ast.FunctionLiteral) *Function {
+	fn := signature(literal)
+
+	state := &function{}
+	if literal.ReturnType != nil {
+		state.declared = fn.Return
+	}
+	c.functions = append(c.functions, state)
+	c.enterScope()
+	c.scope.function = true
+
+	for i, parameter := range literal.Parameters {
+		c.define(parameter.Value, fn.Parameters[i], literal.ParameterTypes != nil && literal.ParameterTypes[i] != nil)
+	}
+
+	// the value of the body is returned unless it ends in a return statement
+	result := c.checkStatements(literal.Body.Statements)
+	if len(literal.Body.Statements) == 0 {
+		c.checkReturn(literal.Body, result)
+	} else {
+		switch last := literal.Body.Statements[len(literal.Body.Statements)-1].(type) {
+		case *ast.ExpressionStatement:
+			c.checkReturn(last.Expression, result)
+		case *ast.ReturnStatement:
+		default:
+			c.checkReturn(literal.Body, Unknown)
+		}
+	}
+
+	c.leaveScope()
+	c.functions = c.functions[:len(c.functions)-1]
+
+	if state.declared == nil {
+		fn.Return = join(state.returns...)
+	}
+	return fn
+}
+
+// infer checks expression and returns its type.
+func (c *Checker) infer(expression ast.Expression) Type {
+	switch node := expression.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.StringLiteral:
+		return String
+	case *ast.InterpolatedString:
+		for _, part := range node.Expressions {
+			c.infer(part)
+		}
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.NullLiteral:
+		return Null
+
+	case *ast.Identifier:
+		if t, ok := c.identifierType(node.Value); ok {
+			return t
+		}
+		if builtin, ok := builtins[node.Value]; ok {
+			return builtin
+		}
+		return Unknown
+
+	case *ast.PrefixExpression:
+		return c.inferPrefix(node, c.infer(node.Right))
+
+	case *ast.InfixExpression:
+		left := c.infer(node.Left)
+		right := c.infer(node.Right)
+		return c.inferInfix(node, node.Operator, left, right)
+
+	case *ast.LogicalExpression:
+		c.infer(node.Left)
+		c.infer(node.Right)
+		return Bool
+
+	case *ast.AssignExpression:
+		return c.inferAssign(node)
+
+	case *ast.UpdateExpression:
+		target := c.infer(node.Target)
+		return c.inferInfix(node, node.Operator[:1], target, Int)
+
+	case *ast.IfExpression:
+		c.infer(node.Condition)
+		name, whenTrue := nullTest(node.Condition)
+		consequence := c.checkNarrowed(node.Consequence, name, whenTrue)
+		if node.Alternative == nil {
+			return join(consequence, Null)
+		}
+		return join(consequence, c.checkNarrowed(node.Alternative, name, !whenTrue))
+
+	case *ast.FunctionLiteral:
+		return c.inferFunction(node)
+
+	case *ast.CallExpression:
+		return c.inferCall(node)
+
+	case *ast.ArrayLiteral:
+		elements := []Type{}
+		for _, element := range node.Elements {
+			elements = append(elements, c.infer(element))
+		}
+		if len(elements) == 0 {
+			return &Array{Element: Unknown}
+		}
+		return &Array{Element: join(elements...)}
+
+	case *ast.HashLiteral:
+		return c.inferHash(node)
+
+	case *ast.IndexExpression:
+		return c.inferIndex(node, c.infer(node.Left), c.infer(node.Index))
+	}
+
+	return Unknown
+}
+
+func (c *Checker) inferPrefix(node *ast.PrefixExpression, right Type) Type {
+	right = nonNull(right)
+	switch {
+	case node.Operator == "!":
+		return Bool
+	case right == Unknown:
+		return Unknown
+	case node.Operator == "-" && all(right, isNumeric):
+		return right
+	case node.Operator == "~" && right == Int:
+		return Int
+	}
+
+	c.errorAt(diagnostic.InvalidOperation, node, "unknown operator: %s%s", node.Operator, right)
+	return Unknown
+}
+
+// inferInfix mirrors the operator matrix of the evaluator: arithmetic on
+// numbers (int when both operands are int), bitwise operators and shifts on
+// ints only, comparisons on numbers and strings, + on strings, and == and !=
+// on any two values. An operand of a union type is valid when the operator
+// applies to each of its members, and the result is the join of theirs.
+func (c *Checker) inferInfix(node ast.Node, operator string, left, right Type) Type {
+	if operator == "==" || operator == "!=" {
+		return Bool
+	}
+
+	left, right = nonNull(left), nonNull(right)
+	if left == Unknown || right == Unknown {
+		if isComparison(operator) {
+			return Bool
+		}
+		return Unknown
+	}
+
+	results := []Type{}
+	for _, l := range members(left) {
+		for _, r := range members(right) {
+			result := operate(operator, l, r)
+			if result == nil {
+				if left.String() != right.String() {
+					c.errorAt(diagnostic.InvalidOperation, node, "type mismatch: %s %s %s", left, operator, right)
+				} else {
+					c.errorAt(diagnostic.InvalidOperation, node, "unknown operator: %s %s %s", left, operator, right)
+				}
+				return Unknown
+			}
+			results = append(results, result)
+		}
+	}
+
+	return join(results...)
+}
+
+// operate returns the type of left operator right for operands that are not
+// unions, or nil if the operator does not apply to them.
+func operate(operator string, left, right Type) Type {
+	switch {
+	case left == Int && right == Int && (isArithmetic(operator) || isBitwise(operator)):
+		return Int
+	case isNumeric(left) && isNumeric(right) && isArithmetic(operator):
+		return Float
+	case isNumeric(left) && isNumeric(right) && isComparison(operator):
+		return Bool
+	case left == String && right == String && operator == "+":
+		return String
+	case left == String && right == String && isComparison(operator):
+		return Bool
+	}
+	return nil
+}
+
+// members returns the members of a union type, or t itself.
+func members(t Type) []Type {
+	if union, ok := t.(*Union); ok {
+		return union.Types
+	}
+	return []Type{t}
+}
+
+// all reports whether every member of t satisfies predicate.
+func all(t Type, predicate func(Type) bool) bool {
+	for _, member := range members(t) {
+		if !predicate(member) {
+			return false
+		}
+	}
+	return true
+}
+
+// nonNull returns the type of t without null. Operations on a nullable
+// value are checked as if the value were present; null itself is left to
+// the runtime check.
+func nonNull(t Type) Type {
+	if nullable, ok := t.(*Nullable); ok {
+		return nullable.Type
+	}
+	return t
+}
+
+func isArithmetic(operator string) bool {
+	switch operator {
+	case "+", "-", "*", "/", "%", "**":
+		return true
+	}
+	return false
+}
+
+func isBitwise(operator string) bool {
+	switch operator {
+	case "&", "|", "^", "<<", ">>":
+		return true
+	}
+	return false
+}
+
+func isComparison(operator string) bool {
+	switch operator {
+	case "<", ">", "<=", ">=":
+		return true
+	}
+	return false
+}
+
+func (c *Checker) inferAssign(node *ast.AssignExpression) Type {
+	if index, ok := node.Target.(*ast.IndexExpression); ok {
+		if root := c.rootBinding(index); root == nil || !root.declared {
+			return c.inferUntypedIndexAssign(node, index, root)
+		}
+	}
+
+	target := c.infer(node.Target)
+	value := c.infer(node.Value)
+	if node.Operator != "=" {
+		value = c.inferInfix(node, node.Operator[:len(node.Operator)-1], target, value)
+	}
+
+	identifier, ok := node.Target.(*ast.Identifier)
+	if !ok {
+		if !assignable(value, target) {
+			c.errorAt(diagnostic.TypeMismatch, node.Value, "type mismatch: cannot assign %s to %s element", value, target)
+		}
+		return value
+	}
+
+	b, ok := c.scope.lookup(identifier.Value)
+	if ok {
+		delete(c.narrowed, b)
+	}
+	switch {
+	case !ok:
+	case b.declared && !assignable(value, b.typ):
+		c.errorAt(diagnostic.TypeMismatch, node.Value,
+			"type mismatch: cannot assign %s to %s variable", value, b.typ)
+	case !b.declared && !assignable(value, b.typ):
+		// an inferred type only describes the initial value
+		b.typ = Unknown
+	}
+
+	return value
+}
+
+// rootBinding returns the binding of the variable at the root of an index
+// expression such as a[1][2], or nil if there is none.
+func (c *Checker) rootBinding(node *ast.IndexExpression) *binding {
+	var root ast.Expression = node
+	for index, ok := root.(*ast.IndexExpression); ok; index, ok = root.(*ast.IndexExpression) {
+		root = index.Left
+	}
+
+	if identifier, ok := root.(*ast.Identifier); ok {
+		if b, ok := c.scope.lookup(identifier.Value); ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// inferUntypedIndexAssign checks a write to an element of a collection
+// whose variable was declared without a type. Like the inferred type of the
+// variable itself, the inferred element type only describes the initial
+// value, so a write that does not fit it makes the variable unknown.
+func (c *Checker) inferUntypedIndexAssign(node *ast.AssignExpression, target *ast.IndexExpression, root *binding) Type {
+	container := c.infer(target.Left)
+	index := c.infer(target.Index)
+	value := c.infer(node.Value)
+
+	element, key := Type(Unknown), Type(Unknown)
+	switch container := container.(type) {
+	case *Array:
+		element, key = container.Element, Int
+	case *Hash:
+		element, key = container.Value, container.Key
+	}
+	if node.Operator != "=" {
+		value = c.inferInfix(node, node.Operator[:len(node.Operator)-1], element, value)
+	}
+
+	if root != nil && (!assignable(value, element) || !assignable(index, key)) {
+		root.typ = Unknown
+	}
+	return value
+}
+
+func (c *Checker) inferCall(node *ast.CallExpression) Type {
+	callee := c.infer(node.Function)
+	arguments := []Type{}
+	for _, argument := range node.Arguments {
+		arguments = append(arguments, c.infer(argument))
+	}
+
+	fn, ok := callee.(*Function)
+	if !ok {
+		switch callee.(type) {
+		case *Union, *Nullable:
+		default:
+			if callee != Unknown {
+				c.errorAt(diagnostic.NotCallable, node.Function, "not a function: %s", callee)
+			}
+		}
+		return Unknown
+	}
+
+	if fn.Variadic {
+		return fn.Return
+	}
+
+	if len(arguments) != len(fn.Parameters) {
+		c.errorAt(diagnostic.ArgumentCount, node, "wrong number of arguments%s: want=%d, got=%d",
+			calleeName(node.Function), len(fn.Parameters), len(arguments))
+		return fn.Return
+	}
+
+	for i, argument := range arguments {
+		if !assignable(argument, fn.Parameters[i]) {
+			c.errorAt(diagnostic.TypeMismatch, node.Arguments[i],
+				"type mismatch: cannot use %s as %s argument%s", argument, fn.Parameters[i], calleeName(node.Function))
+		}
+	}
+
+	return fn.Return
+}
+
+func calleeName(callee ast.Expression) string {
+	if identifier, ok := callee.(*ast.Identifier); ok {
+		return " to `" + identifier.Value + "`"
+	}
+	return ""
+}
+
+func (c *Checker) inferHash(node *ast.HashLiteral) Type {
+	// check the pairs in source order
+	pairs := []ast.Expression{}
+	for key := range node.Pairs {
+		pairs = append(pairs, key)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Pos().Offset < pairs[j].Pos().Offset
+	})
+
+	keys := []Type{}
+	values := []Type{}
+	for _, key := range pairs {
+		keyType := c.infer(key)
+		switch keyType {
+		case Int, String, Bool, Unknown:
+		default:
+			c.errorAt(diagnostic.InvalidHashKey, key, "unusable as hash key: %s", keyType)
+			keyType = Unknown
+		}
+		keys = append(keys, keyType)
+		values = append(values, c.infer(node.Pairs[key]))
+	}
+
+	if len(node.Pairs) == 0 {
+		return &Hash{Key: Unknown, Value: Unknown}
+	}
+	return &Hash{Key: join(keys...), Value: join(values...)}
+}
+
+// inferIndex returns the element type of an index expression. A missing
+// element evaluates to null, which is left out of the type so that indexing
+// stays usable in arithmetic.
+func (c *Checker) inferIndex(node *ast.IndexExpression, left, index Type) Type {
+	switch left := left.(type) {
+	case *Array:
+		if !assignable(index, Int) {
+			c.errorAt(diagnostic.TypeMismatch, node.Index, "array index must be int, got %s", index)
+		}
+		return left.Element
+	case *Hash:
+		if !assignable(index, left.Key) {
+			c.errorAt(diagnostic.TypeMismatch, node.Index, "hash key must be %s, got %s", left.Key, index)
+		}
+		return left.Value
+	case *Union, *Nullable:
+		return Unknown
+	}
+
+	switch left {
+	case Unknown:
+		return Unknown
+	case String:
+		if !assignable(index, Int) {
+			c.errorAt(diagnostic.TypeMismatch, node.Index, "string index must be int, got %s", index)
+		}
+		return String
+	}
+
+	c.errorAt(diagnostic.NotIndexable, node, "index operator not supported: %s", left)
+	return Unknown
+}
+
+// span returns the region of source covered by node. Nodes do not record
+// where they end, so the span ends after the last token that is known.
+func span(node ast.Node) diagnostic.Span {
+	return diagnostic.Span{Start: node.Pos(), End: end(node)}
+}
+
+func end(node ast.Node) token.Position {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		return node.Token.End
+	case *ast.IntegerLiteral:
+		return node.Token.End
+	case *ast.FloatLiteral:
+		return node.Token.End
+	case *ast.StringLiteral:
+		return node.Token.End
+	case *ast.Boolean:
+		return node.Token.End
+	case *ast.NullLiteral:
+		return node.Token.End
+	case *ast.PrefixExpression:
+		return end(node.Right)
+	case *ast.InfixExpression:
+		return end(node.Right)
+	case *ast.LogicalExpression:
+		return end(node.Right)
+	case *ast.AssignExpression:
+		return end(node.Value)
+	case *ast.UpdateExpression:
+		if node.Prefix {
+			return end(node.Target)
+		}
+		return node.Token.End
+	}
+
+	return node.Pos()
+}
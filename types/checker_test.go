@@ -0,0 +1,316 @@
+package types
+
+import (
+	"Go-Tutorials/Core-lang/ast"
+	"Go-Tutorials/Core-lang/lexer"
+	"Go-Tutorials/Core-lang/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	par := parser.New(lexer.New(input))
+	program := par.ParseProgram()
+	if len(par.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, par.Errors())
+	}
+	return program
+}
+
+func TestInferredTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5", "int"},
+		{"5.5", "float"},
+		{`"a"`, "string"},
+		{"true", "bool"},
+		{"null", "null"},
+		{"1 + 2 * 3", "int"},
+		{"1 + 2.5", "float"},
+		{"2 ** 3 % 5", "int"},
+		{"~1 << 2", "int"},
+		{"-2.5", "float"},
+		{`"a" + "b"`, "string"},
+		{`"a" < "b"`, "bool"},
+		{"1 <= 2.5", "bool"},
+		{"[1, 2] == 3", "bool"},
+		{"!5", "bool"},
+		{"1 && 0", "bool"},
+		{"[1, 2, 3]", "[int]"},
+		{`[1, "a"]`, "[int | string]"},
+		{"[]", "[unknown]"},
+		{`{"a": 1, "b": 2}`, "{string: int}"},
+		{`{"a": 1}["a"]`, "int"},
+		{"[1, 2][0]", "int"},
+		{`"abc"[1]`, "string"},
+		{"var int x = 1; x", "int"},
+		{"var [string] xs = []; xs", "[string]"},
+		{"var int? x = null; x", "int?"},
+		{"if (true) { 1 } else { 2 }", "int"},
+		{`if (true) { 1 } else { "a" }`, "int | string"},
+		{"if (true) { 1 }", "int?"},
+		{"if (true) { 1 } else { null }", "int?"},
+		{"function(int x): int { x }", "function(int): int"},
+		{"function(x) { x }", "function(unknown)"},
+		{"function() { 1 }", "function(): int"},
+		{`function(bool b) { if (b) { return 1; } "a" }`, "function(bool): int | string"},
+		{"function(int x): int { x }(1)", "int"},
+		{"function f(): float { 1.5 } f()", "float"},
+		{"g(); function g() { true }", "unknown"},
+		{"function g() { true } g()", "bool"},
+		{"len([1])", "int"},
+		{`string(1) + "a"`, "string"},
+		{"range(3)", "range"},
+		{"undefined", "unknown"},
+		{"var int x = 0; x += 1", "int"},
+		{"var float x = 0.5; x++", "float"},
+		{"var int? x = 1; if (x != null) { x } else { 0 }", "int"},
+		{"var int? x = 1; x * 2", "int"},
+		{"var int | float x = 1; x * 2", "int | float"},
+		{"var int | float x = 1; x / 2.5", "float"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		checker := NewChecker()
+		var result Type
+		for _, statement := range program.Statements {
+			result = checker.checkStatement(statement)
+		}
+		if len(checker.errors) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, checker.errors)
+			continue
+		}
+		if result.String() != tt.expected {
+			t.Errorf("wrong type for %q. expected=%q, got=%q", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestInferredVarTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var x = 1;", "int"},
+		{"var x = 1 + 2.5;", "float"},
+		{`var x = "a" + "b";`, "string"},
+		{"var x = 1 < 2;", "bool"},
+		{"var x = [1, 2];", "[int]"},
+		{`var x = {"a": [1.5]};`, "{string: [float]}"},
+		{"var x = if (true) { 1 };", "int?"},
+		{`var x = if (true) { 1 } else { "a" };`, "int | string"},
+		{"var x = function(int a): bool { a > 0 };", "function(int): bool"},
+		{"var x = function(): int | string { 1 };", "function(): int | string"},
+		{"var x = function(string s) { return s; };", "function(string): string"},
+		{"var x = null;", ""},
+		{"var x = [];", ""},
+		{"var x = function(a) { a };", ""},
+		{"var x = function() { };", ""},
+		{"var x = len;", ""},
+		{"var x = range(3);", ""},
+		{"var x = y;", ""},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		if errors := Check(program); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, errors)
+			continue
+		}
+
+		statement := program.Statements[0].(*ast.VarStatement)
+		if statement.Type != nil {
+			t.Errorf("statement.Type not nil for %q. got=%s", tt.input, statement.Type)
+		}
+
+		inferred := ""
+		if statement.InferredType != nil {
+			inferred = statement.InferredType.String()
+		}
+		if inferred != tt.expected {
+			t.Errorf("wrong inferred type for %q. expected=%q, got=%q", tt.input, tt.expected, inferred)
+		}
+	}
+}
+
+func TestValidPrograms(t *testing.T) {
+	tests := []string{
+		"var int x = 1 + 2;",
+		"var float x = 1 + 2.5;",
+		"var int a = 1; var int b = a * 2;",
+		"var int? x = null;",
+		"var int | string x = 1;",
+		`var int | string x = if (true) { 1 } else { "a" };`,
+		"var [int] xs = [];",
+		"var [int?] xs = [1, null];",
+		`var {string: int} h = {"a": 1};`,
+		"var function(int): int f = function(int x): int { x * 2 };",
+		"var function(int): int f = function(x) { x };",
+		"var int n = len([1, 2]);",
+		"var int x = 1; x = 2;",
+		"var x = 1; var int y = x + 1;",
+		"var x = 1; x = \"a\"; var string s = x;",
+		"function f(x) { x } var int y = f(1); var string z = f(\"a\");",
+		"var int x = fib(10); function fib(int n): int { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }",
+		"function f(int n): int { while (true) { return n; } }",
+		"for (x in [1, 2]) { var int y = x; }",
+		`for (k, v in {"a": 1.5}) { var string key = k; var float value = v; }`,
+		`for (k in {"a": 1.5}) { var string key = k; }`,
+		`for (c in "abc") { var string s = c; }`,
+		"for (i in range(3)) { var int n = i; }",
+		"for (var int i = 0; i < 3; i++) { var int n = i; }",
+		"var [int] xs = [1]; xs[0] = 2;",
+		"for (x in [1, \"a\"]) { x = 2; }",
+		"function(a, b) { a + b }",
+		"function(int a): int { var int b = a; return b; }",
+		"function(): int? { }",
+		"var string s = \"${1 + 2}\";",
+		"var bool b = 1 < 2 && \"a\" != \"b\";",
+		"var a = [1, 2]; a[0] = \"s\";",
+		"var h = {\"a\": 1}; h[\"b\"] = \"x\";",
+		"var a = [1, 2]; a[0] = \"s\"; a[0] + \"x\"",
+		"var h = {\"a\": 1}; h[1] = 2; h[1] + 1",
+		"var m = [[1]]; m[0][0] = \"s\"; var string s = m[0][0];",
+		"var [int] xs = [1]; var ys = xs; ys[0] = 2;",
+		"var x = 1; var g = function() { x + \"a\" }; x = \"s\"; g()",
+		"var x = 1; function g() { x + \"a\" } x = \"s\"; g()",
+		"function f(n) { if (n > 0) { f(n - 1) } else { 1 } } var int y = f(3);",
+		"var int? x = 1; if (x != null) { x + 1 }",
+		"var int? x = 1; if (null != x) { var int y = x; }",
+		"var int? x = 1; if (x == null) { 0 } else { var int y = x; }",
+		"var int? x = 1; function() { if (x != null) { var int y = x; } }",
+		"var int? x = 1; if (x != null) { x = null; }",
+		"var int? x = 1; x + 1",
+		"var float? f = 1.5; -f",
+		"var x = 1; if (true) { var x = \"s\"; } x + \"a\"",
+		"var x = 1; if (false) { var x = \"s\"; } x + 1",
+		"var int x = 1; while (false) { var string x = \"s\"; } var int y = x;",
+		"var x = 1; function() { if (true) { var x = \"s\"; } x + 1 }",
+		"if (true) { var int y = 1; } var int z = y;",
+		"var x = if (true) { 1 } else { 2.5 }; x * 2;",
+		"[1, 2.5][0] + 1;",
+		"function h(int x) { if (x > 10) { return 1; } 2.5 } h(4) + 1;",
+		"var int | float x = 1; var float y = x * 2.5; var bool b = x < 2;",
+		"var int | float x = 1; -x",
+		"var (int | float)? x = null; x + 1",
+	}
+
+	for _, input := range tests {
+		errors := Check(parse(t, input))
+		for _, diag := range errors {
+			t.Errorf("unexpected error for %q: %s", input, diag.Error())
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		line     int
+		column   int
+	}{
+		{"var string b = 5;", "type mismatch: cannot assign int to string variable", 1, 16},
+		{"var int a = \"abc\";", "type mismatch: cannot assign string to int variable", 1, 13},
+		{"var int a = 1;\nvar string b = a;", "type mismatch: cannot assign int to string variable", 2, 16},
+		{"var int a = 1.5 * 2;", "type mismatch: cannot assign float to int variable", 1, 13},
+		{"var int a = function() { 1 };", "type mismatch: cannot assign function(): int to int variable", 1, 13},
+		{"var int a = if (true) { 1 };", "type mismatch: cannot assign int? to int variable", 1, 13},
+		{"var [int] xs = [1, \"a\"];", "type mismatch: cannot assign [int | string] to [int] variable", 1, 16},
+		{"var int a = 1; a = \"s\";", "type mismatch: cannot assign string to int variable", 1, 20},
+		{"var a = 1; var string b = a;", "type mismatch: cannot assign int to string variable", 1, 27},
+		{"var a = \"s\"; a - 1;", "type mismatch: string - int", 1, 14},
+		{"var string s = \"a\"; s += 1;", "type mismatch: string + int", 1, 21},
+		{"var [int] xs = [1]; xs[0] = true;", "type mismatch: cannot assign bool to int element", 1, 29},
+		{"var a = [1]; a[0] -= \"s\";", "type mismatch: int - string", 1, 14},
+		{"var [int] xs = [1]; function() { xs[0] = \"s\" }", "type mismatch: cannot assign string to int element", 1, 42},
+		{"var int x = 1; function() { var string s = x; }", "type mismatch: cannot assign int to string variable", 1, 44},
+		{"function f(int a) { a } function() { f(\"s\") }", "type mismatch: cannot use string as int argument to `f`", 1, 40},
+		{"function() { var x = 1; x + \"a\" }", "type mismatch: int + string", 1, 25},
+		{"var int? x = 1; if (x == null) { var int y = x; }", "type mismatch: cannot assign int? to int variable", 1, 46},
+		{"var int? x = 1; if (x != null) { x = null; var int y = x; }", "type mismatch: cannot assign int? to int variable", 1, 56},
+		{"var int? x = 1; if (x != null) { 1 } var int y = x;", "type mismatch: cannot assign int? to int variable", 1, 50},
+		{"var string? s = null; s - 1", "type mismatch: string - int", 1, 23},
+		{"null + 1", "type mismatch: null + int", 1, 1},
+		{"1 + true", "type mismatch: int + bool", 1, 1},
+		{"true + false", "unknown operator: bool + bool", 1, 1},
+		{"1.5 & 2", "type mismatch: float & int", 1, 1},
+		{"\"a\" - \"b\"", "unknown operator: string - string", 1, 1},
+		{"-true", "unknown operator: -bool", 1, 1},
+		{"~1.5", "unknown operator: ~float", 1, 1},
+		{"var int | string x = 1; x * 2;", "type mismatch: int | string * int", 1, 25},
+		{"var int | string x = 1; x < x;", "unknown operator: int | string < int | string", 1, 25},
+		{"var bool b = true; b++;", "type mismatch: bool + int", 1, 20},
+		{"var int x = 1; x();", "not a function: int", 1, 16},
+		{"function f(int a) { a } f(1, 2);", "wrong number of arguments to `f`: want=1, got=2", 1, 25},
+		{"function(int a) { a }(\"a\");", "type mismatch: cannot use string as int argument", 1, 23},
+		{"len(1, 2);", "wrong number of arguments to `len`: want=1, got=2", 1, 1},
+		{"function(): int { \"a\" }", "type mismatch: cannot return string from function returning int", 1, 19},
+		{"function(): int { return true; }", "type mismatch: cannot return bool from function returning int", 1, 26},
+		{"function(): int { }", "type mismatch: cannot return null from function returning int", 1, 17},
+		{"function(int a): int { return null; }", "type mismatch: cannot return null from function returning int", 1, 31},
+		{"for (x in 5) { }", "cannot iterate over int", 1, 11},
+		{"5[0]", "index operator not supported: int", 1, 1},
+		{"[1][\"a\"]", "array index must be int, got string", 1, 5},
+		{"{1: 2}[\"a\"]", "hash key must be int, got string", 1, 8},
+		{"{[1]: 2}", "unusable as hash key: [int]", 1, 2},
+		{"function f(): string { 1 } var string x = f();", "type mismatch: cannot return int from function returning string", 1, 24},
+		{"var int x = 1; if (true) { var string y = x; }", "type mismatch: cannot assign int to string variable", 1, 43},
+		{"if (true) { var int y = 1; } var string s = y;", "type mismatch: cannot assign int to string variable", 1, 45},
+		{"var x = 1; if (true) { var x = 2; } var string s = x;", "type mismatch: cannot assign int to string variable", 1, 52},
+		{"function(int a) { var string s = a; }", "type mismatch: cannot assign int to string variable", 1, 34},
+	}
+
+	for _, tt := range tests {
+		errors := Check(parse(t, tt.input))
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+
+		diag := errors[0]
+		if diag.Message != tt.expected {
+			t.Errorf("wrong message for %q. expected=%q, got=%q", tt.input, tt.expected, diag.Message)
+		}
+		if diag.Span.Start.Line != tt.line || diag.Span.Start.Column != tt.column {
+			t.Errorf("wrong position for %q. expected=%d:%d, got=%d:%d", tt.input,
+				tt.line, tt.column, diag.Span.Start.Line, diag.Span.Start.Column)
+		}
+	}
+}
+
+func TestCheckerKeepsBindings(t *testing.T) {
+	checker := NewChecker()
+
+	if errors := checker.Check(parse(t, "var int x = 1;")); len(errors) != 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+
+	errors := checker.Check(parse(t, "var string y = x;"))
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errors), errors)
+	}
+	if errors[0].Message != "type mismatch: cannot assign int to string variable" {
+		t.Errorf("wrong message. got=%q", errors[0].Message)
+	}
+}
+
+func TestCheckerDropsRejectedBindings(t *testing.T) {
+	checker := NewChecker()
+	checker.Check(parse(t, "var x = 1;"))
+
+	if errors := checker.Check(parse(t, `var y = 1; x = "s"; 1 + "a"`)); len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errors), errors)
+	}
+
+	// neither the declaration of y nor the reassignment of x took effect
+	errors := checker.Check(parse(t, `var string s = x; y`))
+	if len(errors) != 1 || errors[0].Message != "type mismatch: cannot assign int to string variable" {
+		t.Fatalf("wrong errors: %v", errors)
+	}
+	if _, ok := checker.scope.lookup("y"); ok {
+		t.Errorf("y is still bound after a rejected program")
+	}
+}
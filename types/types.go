@@ -0,0 +1,325 @@
+// Package types implements a static type checker for Core programs.
+package types
+
+import (
+	"Go-Tutorials/Core-lang/ast"
+	"Go-Tutorials/Core-lang/token"
+	"strings"
+)
+
+// Type is the static type of an expression.
+type Type interface {
+	String() string
+}
+
+type basic struct {
+	name string
+}
+
+func (b *basic) String() string { return b.name }
+
+var (
+	Int    Type = &basic{"int"}
+	Float  Type = &basic{"float"}
+	String Type = &basic{"string"}
+	Bool   Type = &basic{"bool"}
+	Null   Type = &basic{"null"}
+	Range  Type = &basic{"range"}
+
+	// Unknown is the type of expressions the checker cannot type, such as
+	// untyped parameters. It is compatible with every other type.
+	Unknown Type = &basic{"unknown"}
+)
+
+type Array struct {
+	Element Type
+}
+
+func (a *Array) String() string { return "[" + a.Element.String() + "]" }
+
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+func (h *Hash) String() string { return "{" + h.Key.String() + ": " + h.Value.String() + "}" }
+
+// Function is the type of a function value. Variadic functions (only the
+// range builtin) accept any number of arguments of any type.
+type Function struct {
+	Parameters []Type
+	Return     Type
+	Variadic   bool
+}
+
+func (f *Function) String() string {
+	if f.Variadic {
+		return "function(...): " + f.Return.String()
+	}
+
+	parameters := []string{}
+	for _, p := range f.Parameters {
+		parameters = append(parameters, p.String())
+	}
+
+	if f.Return == Unknown {
+		return "function(" + strings.Join(parameters, ", ") + ")"
+	}
+	return "function(" + strings.Join(parameters, ", ") + "): " + f.Return.String()
+}
+
+type Nullable struct {
+	Type Type
+}
+
+func (n *Nullable) String() string {
+	if _, ok := n.Type.(*Union); ok || hasReturnType(n.Type) {
+		return "(" + n.Type.String() + ")?"
+	}
+	return n.Type.String() + "?"
+}
+
+type Union struct {
+	Types []Type
+}
+
+func (u *Union) String() string {
+	types := []string{}
+	for _, t := range u.Types {
+		if hasReturnType(t) {
+			types = append(types, "("+t.String()+")")
+		} else {
+			types = append(types, t.String())
+		}
+	}
+
+	return strings.Join(types, " | ")
+}
+
+// FromAnnotation converts a type annotation to a Type. It returns nil for a
+// missing annotation.
+func FromAnnotation(annotation ast.TypeExpression) Type {
+	switch annotation := annotation.(type) {
+	case nil:
+		return nil
+	case *ast.NamedType:
+		switch annotation.Name {
+		case "int":
+			return Int
+		case "float":
+			return Float
+		case "string":
+			return String
+		case "bool":
+			return Bool
+		}
+	case *ast.ArrayType:
+		return &Array{Element: FromAnnotation(annotation.Element)}
+	case *ast.HashType:
+		return &Hash{Key: FromAnnotation(annotation.Key), Value: FromAnnotation(annotation.Value)}
+	case *ast.FunctionType:
+		function := &Function{Return: Unknown}
+		for _, parameter := range annotation.Parameters {
+			function.Parameters = append(function.Parameters, FromAnnotation(parameter))
+		}
+		if annotation.Return != nil {
+			function.Return = FromAnnotation(annotation.Return)
+		}
+		return function
+	case *ast.NullableType:
+		return join(FromAnnotation(annotation.Type), Null)
+	case *ast.UnionType:
+		members := []Type{}
+		for _, member := range annotation.Types {
+			members = append(members, FromAnnotation(member))
+		}
+		return join(members...)
+	}
+
+	return Unknown
+}
+
+// ToAnnotation converts t back to a type annotation. It returns nil when t
+// cannot be written in the source: null, range, and types containing
+// unknown.
+func ToAnnotation(t Type) ast.TypeExpression {
+	switch t := t.(type) {
+	case *basic:
+		tokenType := token.LookupIdentifier(t.name)
+		if tokenType == token.IDENT || t == Null {
+			return nil
+		}
+		return &ast.NamedType{Token: token.Token{Type: tokenType, Literal: t.name}, Name: t.name}
+
+	case *Array:
+		element := ToAnnotation(t.Element)
+		if element == nil {
+			return nil
+		}
+		return &ast.ArrayType{Token: token.Token{Type: token.LEFT_BRACKET, Literal: "["}, Element: element}
+
+	case *Hash:
+		key, value := ToAnnotation(t.Key), ToAnnotation(t.Value)
+		if key == nil || value == nil {
+			return nil
+		}
+		return &ast.HashType{Token: token.Token{Type: token.LEFT_CURLY_BRACE, Literal: "{"}, Key: key, Value: value}
+
+	case *Function:
+		if t.Variadic {
+			return nil
+		}
+		function := &ast.FunctionType{Token: token.Token{Type: token.FUNCTION, Literal: "function"}}
+		for _, parameter := range t.Parameters {
+			annotation := ToAnnotation(parameter)
+			if annotation == nil {
+				return nil
+			}
+			function.Parameters = append(function.Parameters, annotation)
+		}
+		if t.Return != Unknown {
+			if function.Return = ToAnnotation(t.Return); function.Return == nil {
+				return nil
+			}
+		}
+		return function
+
+	case *Nullable:
+		annotation := ToAnnotation(t.Type)
+		if annotation == nil {
+			return nil
+		}
+		return &ast.NullableType{Token: token.Token{Type: token.QUESTION, Literal: "?"}, Type: annotation}
+
+	case *Union:
+		union := &ast.UnionType{Token: token.Token{Type: token.BIT_OR, Literal: "|"}}
+		for _, member := range t.Types {
+			annotation := ToAnnotation(member)
+			if annotation == nil {
+				return nil
+			}
+			union.Types = append(union.Types, annotation)
+		}
+		return union
+	}
+
+	return nil
+}
+
+// join returns the smallest type that admits a value of any of types: the
+// type itself if they are all the same, a nullable type for one type and
+// null, and a union otherwise. Joining with Unknown gives Unknown.
+func join(types ...Type) Type {
+	members := []Type{}
+	seen := map[string]bool{}
+	nullable := false
+
+	var add func(t Type)
+	add = func(t Type) {
+		switch t := t.(type) {
+		case *Union:
+			for _, member := range t.Types {
+				add(member)
+			}
+		case *Nullable:
+			nullable = true
+			add(t.Type)
+		default:
+			if t == Null {
+				nullable = true
+			} else if !seen[t.String()] {
+				seen[t.String()] = true
+				members = append(members, t)
+			}
+		}
+	}
+
+	for _, t := range types {
+		if t == Unknown {
+			return Unknown
+		}
+		add(t)
+	}
+
+	var result Type
+	switch len(members) {
+	case 0:
+		return Null
+	case 1:
+		result = members[0]
+	default:
+		result = &Union{Types: members}
+	}
+
+	if nullable {
+		return &Nullable{Type: result}
+	}
+	return result
+}
+
+// assignable reports whether a value of type from may be stored where a
+// value of type to is expected.
+func assignable(from, to Type) bool {
+	if from == Unknown || to == Unknown || from.String() == to.String() {
+		return true
+	}
+
+	switch from := from.(type) {
+	case *Union:
+		for _, member := range from.Types {
+			if !assignable(member, to) {
+				return false
+			}
+		}
+		return true
+	case *Nullable:
+		return assignable(Null, to) && assignable(from.Type, to)
+	}
+
+	switch to := to.(type) {
+	case *Nullable:
+		return from == Null || assignable(from, to.Type)
+	case *Union:
+		for _, member := range to.Types {
+			if assignable(from, member) {
+				return true
+			}
+		}
+		return false
+	case *Array:
+		array, ok := from.(*Array)
+		return ok && assignable(array.Element, to.Element)
+	case *Hash:
+		hash, ok := from.(*Hash)
+		return ok && assignable(hash.Key, to.Key) && assignable(hash.Value, to.Value)
+	case *Function:
+		function, ok := from.(*Function)
+		if !ok {
+			return false
+		}
+		if function.Variadic || to.Variadic {
+			return true
+		}
+		if len(function.Parameters) != len(to.Parameters) {
+			return false
+		}
+		for i, parameter := range function.Parameters {
+			if !assignable(to.Parameters[i], parameter) {
+				return false
+			}
+		}
+		return assignable(function.Return, to.Return)
+	}
+
+	return false
+}
+
+// hasReturnType mirrors the ast helper of the same name.
+func hasReturnType(t Type) bool {
+	function, ok := t.(*Function)
+	return ok && (function.Variadic || function.Return != Unknown)
+}
+
+func isNumeric(t Type) bool {
+	return t == Int || t == Float
+}